@@ -0,0 +1,312 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Page[R any] struct {
+	Items []R
+	Total int64
+	Page  int
+	Size  int
+}
+
+// AggregatePage runs pipeline and returns a single page of its results along with the
+// total number of results, using a $facet stage so both come back in one round trip. Pages are
+// numbered from 0 & size must be positive.
+func AggregatePage[R any, T any](ctx context.Context, repo *MongoRepository[T], pipeline []bson.M, page, size int) (Page[R], error) {
+	result := Page[R]{Page: page, Size: size}
+	if page < 0 {
+		return result, fmt.Errorf("page must not be negative, got %d", page)
+	}
+	if size <= 0 {
+		return result, fmt.Errorf("page size must be positive, got %d", size)
+	}
+	facetPipeline := append(repo.scopedPipeline(pipeline), bson.M{
+		"$facet": bson.M{
+			"data":  []bson.M{{"$skip": page * size}, {"$limit": size}},
+			"total": []bson.M{{"$count": "count"}},
+		},
+	})
+
+	collection, err := repo.readCollection(nil)
+	if err != nil {
+		return result, err
+	}
+	var facet struct {
+		Data  []R `bson:"data"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	err = repo.execute(ctx, "AggregatePage", facetPipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, facetPipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		if cursor.Next(ctx) {
+			if err := cursor.Decode(&facet); err != nil {
+				return err
+			}
+		}
+		return cursor.Err()
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Items = facet.Data
+	// $count emits no document when nothing matched, leaving total empty
+	if len(facet.Total) > 0 {
+		result.Total = facet.Total[0].Count
+	}
+	return result, nil
+}
+
+// Iterator decodes the documents of a cursor into R one at a time, so that large results aren't
+// held in memory at once. It must be closed once done with.
+type Iterator[R any] struct {
+	cursor *mongo.Cursor
+	value  R
+	err    error
+}
+
+func (it *Iterator[R]) Next(ctx context.Context) bool {
+	if it.err != nil || !it.cursor.Next(ctx) {
+		return false
+	}
+	var value R
+	if err := it.cursor.Decode(&value); err != nil {
+		it.err = err
+		return false
+	}
+	it.value = value
+	return true
+}
+
+func (it *Iterator[R]) Value() R {
+	return it.value
+}
+
+func (it *Iterator[R]) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.cursor.Err()
+}
+
+func (it *Iterator[R]) Close() error {
+	return it.cursor.Close(context.Background())
+}
+
+// AggregateStream runs pipeline, returning an iterator over its results rather than buffering them
+func AggregateStream[R any, T any](ctx context.Context, repo *MongoRepository[T], pipeline []bson.M) (*Iterator[R], error) {
+	collection, err := repo.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var cursor *mongo.Cursor
+	pipeline = repo.scopedPipeline(pipeline)
+	err = repo.execute(ctx, "AggregateStream", pipeline, func(ctx context.Context) (err error) {
+		cursor, err = collection.Aggregate(ctx, pipeline)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator[R]{cursor: cursor}, nil
+}
+
+// LookupAndDecode joins the documents of the from collection whose foreignField equals localField,
+// storing them under as, then runs the extra stages & decodes the results into R. The as field of
+// R is usually a slice, a struct or pointer field unwinds it into one result per joined document.
+func LookupAndDecode[R any, T any](ctx context.Context, repo *MongoRepository[T], from, localField, foreignField, as string, extra ...bson.M) ([]R, error) {
+	pipeline := []bson.M{{"$lookup": bson.M{
+		"from":         from,
+		"localField":   localField,
+		"foreignField": foreignField,
+		"as":           as,
+	}}}
+
+	if t := reflect.TypeOf((*R)(nil)).Elem(); t.Kind() == reflect.Struct {
+		field, ok := fieldTypeByBsonName(t, as)
+		if !ok {
+			return nil, fmt.Errorf("lookup field %s is not part of %s", as, t)
+		}
+		if field.Kind() != reflect.Slice && field.Kind() != reflect.Array {
+			pipeline = append(pipeline, bson.M{"$unwind": bson.M{"path": "$" + as, "preserveNullAndEmptyArrays": true}})
+		}
+	}
+	pipeline = repo.scopedPipeline(append(pipeline, extra...))
+
+	collection, err := repo.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []R
+	err = repo.execute(ctx, "LookupAndDecode", pipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
+	return results, err
+}
+
+// fieldTypeByBsonName also finds fields of structs inlined into t
+func fieldTypeByBsonName(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isIgnored(field) {
+			continue
+		}
+		if isInline(field) {
+			if nested, ok := nestedStructType(field.Type); ok {
+				if found, ok := fieldTypeByBsonName(nested, name); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+		if getFieldName(field) == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// GroupBy fetches the documents matching filter & groups them in memory by the value of their
+// keyField. Every matching document is held in memory, so large sets should be grouped with an
+// aggregation pipeline instead, such as a $group stage through AggregateTyped.
+func GroupBy[K comparable, T any](ctx context.Context, repo *MongoRepository[T], keyField string, filter bson.M) (map[K][]T, error) {
+	keyType := reflect.TypeOf((*K)(nil)).Elem()
+	zero, ok := fieldByBsonName(reflect.ValueOf(new(T)).Elem(), keyField)
+	if !ok {
+		return nil, fmt.Errorf("group field %s is not part of %T", keyField, *new(T))
+	}
+	// converting between kinds such as int to string isn't a meaningful key
+	if fieldType := zero.Type(); fieldType.Kind() != keyType.Kind() || !fieldType.ConvertibleTo(keyType) {
+		return nil, fmt.Errorf("group field %s of type %s cannot be used as a %s key", keyField, fieldType, keyType)
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+	items, err := repo.QueryRunner().Context(ctx).FilterB(filter).QueryMany()
+	if err != nil {
+		return nil, err
+	}
+	groups := map[K][]T{}
+	for _, item := range items {
+		field, _ := fieldByBsonName(reflect.ValueOf(item), keyField)
+		key := field.Convert(keyType).Interface().(K)
+		groups[key] = append(groups[key], item)
+	}
+	return groups, nil
+}
+
+// GroupByFields returns a $group stage counting documents by the values of fields, as a composite
+// _id keyed by field name with dots turned into underscores. The _id decodes into a struct whose
+// fields are named alike, R's key field being tagged bson:"_id":
+//
+//	type StatusAge struct {
+//		Key struct {
+//			Status string `bson:"status"`
+//			Age    int    `bson:"age"`
+//		} `bson:"_id"`
+//		Count int `bson:"count"`
+//	}
+func GroupByFields(fields ...string) bson.M {
+	if len(fields) == 0 {
+		panic("GroupByFields needs at least one field")
+	}
+	id := bson.M{}
+	for _, field := range fields {
+		id[strings.ReplaceAll(field, ".", "_")] = "$" + field
+	}
+	return bson.M{"$group": bson.M{"_id": id, "count": bson.M{"$sum": 1}}}
+}
+
+// CountByField counts the documents matching filter grouped by the value of field. Values are
+// keyed by their fmt.Sprint form, documents missing the field being counted under "<nil>".
+func (r *MongoRepository[T]) CountByField(ctx context.Context, field string, filter bson.M) (map[string]int64, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+	}
+
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		Value interface{} `bson:"_id"`
+		Count int64       `bson:"count"`
+	}
+	pipeline = r.scopedPipeline(pipeline)
+	err = r.execute(ctx, "CountByField", pipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &groups)
+	})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(groups))
+	for _, group := range groups {
+		counts[fmt.Sprint(group.Value)] += group.Count
+	}
+	return counts, nil
+}
+
+// AsFloat reads a numeric aggregation result such as the output of $sum or $avg, which the
+// server returns as an int32, int64, float64 or Decimal128 depending on its inputs.
+func AsFloat(result bson.M, key string) (float64, error) {
+	switch value := result[key].(type) {
+	case int32:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	case primitive.Decimal128:
+		return strconv.ParseFloat(value.String(), 64)
+	case nil:
+		return 0, fmt.Errorf("field %s is missing", key)
+	default:
+		return 0, fmt.Errorf("field %s of type %T is not numeric", key, value)
+	}
+}
+
+// AsDecimal is like AsFloat but returns a Decimal128, keeping Decimal128 results exact
+func AsDecimal(result bson.M, key string) (primitive.Decimal128, error) {
+	switch value := result[key].(type) {
+	case int32:
+		return primitive.ParseDecimal128(strconv.FormatInt(int64(value), 10))
+	case int64:
+		return primitive.ParseDecimal128(strconv.FormatInt(value, 10))
+	case float64:
+		return primitive.ParseDecimal128(strconv.FormatFloat(value, 'g', -1, 64))
+	case primitive.Decimal128:
+		return value, nil
+	case nil:
+		return primitive.Decimal128{}, fmt.Errorf("field %s is missing", key)
+	default:
+		return primitive.Decimal128{}, fmt.Errorf("field %s of type %T is not numeric", key, value)
+	}
+}
@@ -0,0 +1,390 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AgeGroup struct {
+	Age   int `bson:"_id"`
+	Count int `bson:"count"`
+}
+
+func TestAggregatePage(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Page 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Page 2", Age: 20, CreatedAt: time.Now()},
+		{Name: "Page 3", Age: 30, CreatedAt: time.Now()},
+		{Name: "Page 4", Age: 40, CreatedAt: time.Now()},
+		{Name: "Page 5", Age: 50, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$age", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+	page, err := AggregatePage[AgeGroup](context.TODO(), repo, pipeline, 1, 2)
+	if err != nil {
+		t.Fatalf("Failed to aggregate page: %v", err)
+	}
+	if page.Total != 4 {
+		t.Fatalf("Expected 4 groups in total, got %d", page.Total)
+	}
+	if len(page.Items) != 2 || page.Items[0].Age != 40 || page.Items[1].Age != 50 {
+		t.Fatalf("Expected second page to hold ages 40 & 50, got %+v", page.Items)
+	}
+}
+
+func TestAggregatePageEmpty(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$age", "count": bson.M{"$sum": 1}}},
+	}
+	page, err := AggregatePage[AgeGroup](context.TODO(), repo, pipeline, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to aggregate page: %v", err)
+	}
+	if page.Total != 0 || len(page.Items) != 0 {
+		t.Fatalf("Expected an empty page, got %+v", page)
+	}
+}
+
+func TestAggregatePageInvalid(t *testing.T) {
+	repo := &MongoRepository[TestModel]{}
+
+	if _, err := AggregatePage[AgeGroup](context.TODO(), repo, nil, -1, 10); err == nil {
+		t.Fatalf("Expected an error for a negative page")
+	}
+	if _, err := AggregatePage[AgeGroup](context.TODO(), repo, nil, 0, 0); err == nil {
+		t.Fatalf("Expected an error for a page size of 0")
+	}
+}
+
+func TestAggregateTyped(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Typed 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Typed 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "Typed 3", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"age": 30}},
+		{"$sort": bson.M{"name": 1}},
+	}
+	results, err := repo.AggregateTyped(context.TODO(), pipeline)
+	if err != nil {
+		t.Fatalf("Failed to aggregate typed results: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Name != "Typed 2" || results[0].Age != 30 || results[0].ID != items[1].ID {
+		t.Fatalf("Expected first result to be 'Typed 2', got %+v", results[0])
+	}
+	if results[1].Name != "Typed 3" || results[1].ID != items[2].ID {
+		t.Fatalf("Expected second result to be 'Typed 3', got %+v", results[1])
+	}
+}
+
+func TestCountByField(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Group 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Group 2", Age: 20, CreatedAt: time.Now()},
+		{Name: "Group 3", Age: 30, CreatedAt: time.Now()},
+		{Name: "Group 4", Age: 40, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	counts, err := repo.CountByField(context.TODO(), "age", bson.M{"age": bson.M{"$lt": 40}})
+	if err != nil {
+		t.Fatalf("Failed to count by field: %v", err)
+	}
+	expected := map[string]int64{"20": 2, "30": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("Expected counts %v, got %v", expected, counts)
+	}
+}
+
+func TestAggregateStream(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Stream 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Stream 2", Age: 20, CreatedAt: time.Now()},
+		{Name: "Stream 3", Age: 30, CreatedAt: time.Now()},
+		{Name: "Stream 4", Age: 40, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$age", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+	it, err := AggregateStream[AgeGroup](context.TODO(), repo, pipeline)
+	if err != nil {
+		t.Fatalf("Failed to stream aggregation: %v", err)
+	}
+	defer it.Close()
+
+	var groups []AgeGroup
+	for it.Next(context.TODO()) {
+		groups = append(groups, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Failed to iterate aggregation: %v", err)
+	}
+	expected := []AgeGroup{{Age: 20, Count: 2}, {Age: 30, Count: 1}, {Age: 40, Count: 1}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("Expected groups %v, got %v", expected, groups)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Group 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Group 2", Age: 20, CreatedAt: time.Now()},
+		{Name: "Group 3", Age: 30, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	groups, err := GroupBy[int](context.TODO(), repo, "age", nil)
+	if err != nil {
+		t.Fatalf("Failed to group by age: %v", err)
+	}
+	if len(groups) != 2 || len(groups[20]) != 2 || len(groups[30]) != 1 {
+		t.Fatalf("Expected 2 items aged 20 & 1 aged 30, got %v", groups)
+	}
+	if groups[30][0].Name != "Group 3" {
+		t.Fatalf("Expected 'Group 3' to be aged 30, got '%s'", groups[30][0].Name)
+	}
+
+	if _, err := GroupBy[string](context.TODO(), repo, "age", nil); err == nil {
+		t.Fatalf("Expected grouping an int field by string keys to be rejected")
+	}
+}
+
+type MemberModel struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Status string             `bson:"status"`
+	Age    int                `bson:"age"`
+}
+
+type StatusAgeGroup struct {
+	Key struct {
+		Status string `bson:"status"`
+		Age    int    `bson:"age"`
+	} `bson:"_id"`
+	Count int `bson:"count"`
+}
+
+func TestGroupByFields(t *testing.T) {
+	repo, err := NewMongoRepository[MemberModel](setupTestCollection(t, "members"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	_, err = repo.SaveAll(context.TODO(), []MemberModel{
+		{Status: "active", Age: 20},
+		{Status: "active", Age: 20},
+		{Status: "active", Age: 30},
+		{Status: "inactive", Age: 20},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save members: %v", err)
+	}
+
+	pipeline := []bson.M{
+		GroupByFields("status", "age"),
+		{"$sort": bson.D{{Key: "_id.status", Value: 1}, {Key: "_id.age", Value: 1}}},
+	}
+	it, err := AggregateStream[StatusAgeGroup](context.TODO(), repo, pipeline)
+	if err != nil {
+		t.Fatalf("Failed to aggregate: %v", err)
+	}
+	defer it.Close()
+
+	var groups []string
+	for it.Next(context.TODO()) {
+		group := it.Value()
+		groups = append(groups, group.Key.Status+"/"+strconv.Itoa(group.Key.Age)+"="+strconv.Itoa(group.Count))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Failed to iterate groups: %v", err)
+	}
+	expected := []string{"active/20=2", "active/30=1", "inactive/20=1"}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("Expected groups %v, got %v", expected, groups)
+	}
+}
+
+type CustomerModel struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Name string             `bson:"name"`
+}
+
+type OrderModel struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	CustomerID primitive.ObjectID `bson:"customer_id"`
+	Total      int                `bson:"total"`
+}
+
+type OrderWithCustomers struct {
+	OrderModel `bson:",inline"`
+	Customers  []CustomerModel `bson:"customers"`
+}
+
+type OrderWithCustomer struct {
+	OrderModel `bson:",inline"`
+	Customer   *CustomerModel `bson:"customer"`
+}
+
+func TestLookupAndDecode(t *testing.T) {
+	customers, err := NewMongoRepository[CustomerModel](setupTestCollection(t, "customers"))
+	if err != nil {
+		t.Fatalf("Failed to create customer repository: %v", err)
+	}
+	orders, err := NewMongoRepository[OrderModel](setupTestCollection(t, "orders"))
+	if err != nil {
+		t.Fatalf("Failed to create order repository: %v", err)
+	}
+
+	customer, err := customers.Save(context.TODO(), CustomerModel{Name: "Joined"})
+	if err != nil {
+		t.Fatalf("Failed to save customer: %v", err)
+	}
+	_, err = orders.SaveAll(context.TODO(), []OrderModel{
+		{CustomerID: customer.ID, Total: 10},
+		{CustomerID: customer.ID, Total: 20},
+		{CustomerID: primitive.NewObjectID(), Total: 30},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save orders: %v", err)
+	}
+
+	sortByTotal := bson.M{"$sort": bson.M{"total": 1}}
+	joined, err := LookupAndDecode[OrderWithCustomers](context.TODO(), orders, "customers", "customer_id", "_id", "customers", sortByTotal)
+	if err != nil {
+		t.Fatalf("Failed to lookup customers: %v", err)
+	}
+	if len(joined) != 3 {
+		t.Fatalf("Expected 3 orders, got %d", len(joined))
+	}
+	if len(joined[0].Customers) != 1 || joined[0].Customers[0].Name != "Joined" {
+		t.Fatalf("Expected order to be joined to its customer, got %+v", joined[0])
+	}
+	if len(joined[2].Customers) != 0 {
+		t.Fatalf("Expected order of unknown customer to join nothing, got %+v", joined[2])
+	}
+
+	single, err := LookupAndDecode[OrderWithCustomer](context.TODO(), orders, "customers", "customer_id", "_id", "customer", sortByTotal)
+	if err != nil {
+		t.Fatalf("Failed to lookup customer: %v", err)
+	}
+	if len(single) != 3 || single[0].Customer == nil || single[0].Customer.Name != "Joined" {
+		t.Fatalf("Expected orders joined to a single customer, got %+v", single)
+	}
+	if single[2].Customer != nil {
+		t.Fatalf("Expected order of unknown customer to keep a nil customer, got %+v", single[2].Customer)
+	}
+
+	_, err = LookupAndDecode[OrderWithCustomer](context.TODO(), orders, "customers", "customer_id", "_id", "buyer")
+	if err == nil {
+		t.Fatalf("Expected lookup into an unknown field to be rejected")
+	}
+}
+
+func TestAsFloatAndAsDecimal(t *testing.T) {
+	decimal, err := primitive.ParseDecimal128("12.5")
+	if err != nil {
+		t.Fatalf("Failed to parse decimal: %v", err)
+	}
+	result := bson.M{
+		"int32":   int32(12),
+		"int64":   int64(12),
+		"float64": 12.5,
+		"decimal": decimal,
+		"name":    "not a number",
+	}
+
+	expected := map[string]float64{"int32": 12, "int64": 12, "float64": 12.5, "decimal": 12.5}
+	for key, want := range expected {
+		got, err := AsFloat(result, key)
+		if err != nil {
+			t.Fatalf("Failed to read %s as float: %v", key, err)
+		}
+		if got != want {
+			t.Fatalf("Expected %s to be %v, got %v", key, want, got)
+		}
+
+		dec, err := AsDecimal(result, key)
+		if err != nil {
+			t.Fatalf("Failed to read %s as decimal: %v", key, err)
+		}
+		if dec.String() != strconv.FormatFloat(want, 'g', -1, 64) {
+			t.Fatalf("Expected %s to be %v, got %s", key, want, dec)
+		}
+	}
+
+	if _, err := AsFloat(result, "name"); err == nil {
+		t.Fatalf("Expected error reading a string as float")
+	}
+	if _, err := AsDecimal(result, "missing"); err == nil {
+		t.Fatalf("Expected error reading a missing field as decimal")
+	}
+}
+
+func TestAsDecimalGroupedSum(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Sum 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Sum 2", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	result, err := repo.AggregateOne(context.TODO(), []bson.M{
+		{"$group": bson.M{
+			"_id":        nil,
+			"intSum":     bson.M{"$sum": "$age"},
+			"decimalSum": bson.M{"$sum": bson.M{"$toDecimal": "$age"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate sums: %v", err)
+	}
+	for _, key := range []string{"intSum", "decimalSum"} {
+		sum, err := AsFloat(result, key)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", key, err)
+		}
+		if sum != 50 {
+			t.Fatalf("Expected %s to be 50, got %v", key, sum)
+		}
+	}
+}
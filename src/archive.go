@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ArchiveById moves the document with the given id into the archive collection, returning
+// ErrNotFound when no document has the id. The move runs in a transaction on replica sets &
+// sharded clusters. Elsewhere the document is archived before being deleted, so that a failure
+// in between leaves it in both collections rather than in neither.
+func (r *MongoRepository[T]) ArchiveById(ctx context.Context, id primitive.ObjectID, archive *mongo.Collection) error {
+	filter := r.scoped(bson.M{"_id": id})
+	return r.execute(ctx, "ArchiveById", filter, func(ctx context.Context) error {
+		if !r.supportsTransactions(ctx) {
+			return r.archive(ctx, filter, archive)
+		}
+		session, err := r.collection.Database().Client().StartSession()
+		if err != nil {
+			return err
+		}
+		defer session.EndSession(ctx)
+		_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+			return nil, r.archive(ctx, filter, archive)
+		})
+		return err
+	})
+}
+
+// archive replaces rather than inserts into the archive, so that retrying after a failed delete succeeds
+func (r *MongoRepository[T]) archive(ctx context.Context, filter bson.M, archive *mongo.Collection) error {
+	var doc bson.Raw
+	err := r.collection.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	_, err = archive.ReplaceOne(ctx, bson.M{"_id": doc.Lookup("_id")}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.DeleteOne(ctx, filter)
+	return err
+}
+
+// supportsTransactions reports whether the server is a replica set member or a mongos
+func (r *MongoRepository[T]) supportsTransactions(ctx context.Context) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := r.collection.Database().RunCommand(ctx, bson.M{"hello": 1}).Decode(&hello)
+	return err == nil && (hello.SetName != "" || hello.Msg == "isdbgrid")
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestArchiveById(t *testing.T) {
+	repo := setupTestRepo(t)
+	archive := setupTestCollection(t, "archive")
+	ctx := context.TODO()
+
+	item, err := repo.Save(context.TODO(), TestModel{Name: "Archived", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	if err := repo.ArchiveById(ctx, item.ID, archive); err != nil {
+		t.Fatalf("Failed to archive item: %v", err)
+	}
+	exists, err := repo.ExistsById(context.TODO(), item.ID)
+	if err != nil {
+		t.Fatalf("Failed to check item: %v", err)
+	}
+	if exists {
+		t.Fatalf("Expected archived item to be removed from the collection")
+	}
+
+	var archived TestModel
+	if err := archive.FindOne(ctx, bson.M{"_id": item.ID}).Decode(&archived); err != nil {
+		t.Fatalf("Failed to find archived item: %v", err)
+	}
+	if archived.Name != "Archived" {
+		t.Fatalf("Expected archived item to be 'Archived', got '%s'", archived.Name)
+	}
+
+	if err := repo.ArchiveById(ctx, primitive.NewObjectID(), archive); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for unknown id, got %v", err)
+	}
+}
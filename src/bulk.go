@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BulkResult struct {
+	InsertedCount int64
+	MatchedCount  int64
+	ModifiedCount int64
+	UpsertedCount int64
+	DeletedCount  int64
+}
+
+// BulkBuilder accumulates inserts, replacements, updates & deletes that are sent to the
+// server in a single ordered BulkWrite by Execute.
+type BulkBuilder[T any] struct {
+	repo   *MongoRepository[T]
+	writes []mongo.WriteModel
+	saves  []bulkSave[T]
+	err    error
+}
+
+// bulkSave is an item inserted or replaced by the bulk write, prepared like Save once Execute
+// has a context for its hooks. Its model holds the item by pointer so the prepared item is written.
+type bulkSave[T any] struct {
+	item   *T
+	insert bool
+}
+
+func (r *MongoRepository[T]) Bulk() *BulkBuilder[T] {
+	return &BulkBuilder[T]{repo: r}
+}
+
+// Insert adds item, which Execute prepares like Save: its hooks run, it is validated & it is
+// given an id when unset
+func (b *BulkBuilder[T]) Insert(item T) *BulkBuilder[T] {
+	b.saves = append(b.saves, bulkSave[T]{item: &item, insert: true})
+	b.writes = append(b.writes, mongo.NewInsertOneModel().SetDocument(&item))
+	return b
+}
+
+func (b *BulkBuilder[T]) Replace(id interface{}, item T) *BulkBuilder[T] {
+	b.saves = append(b.saves, bulkSave[T]{item: &item})
+	b.writes = append(b.writes, mongo.NewReplaceOneModel().SetFilter(b.repo.scoped(bson.M{"_id": id})).SetReplacement(&item))
+	return b
+}
+
+func (b *BulkBuilder[T]) Update(filter bson.M, update bson.M) *BulkBuilder[T] {
+	if err := b.repo.checkScopeFields(updateFields(update)...); err != nil && b.err == nil {
+		b.err = err
+	}
+	b.writes = append(b.writes, mongo.NewUpdateManyModel().SetFilter(b.repo.scoped(filter)).SetUpdate(update))
+	return b
+}
+
+func (b *BulkBuilder[T]) Delete(filter bson.M) *BulkBuilder[T] {
+	b.writes = append(b.writes, mongo.NewDeleteManyModel().SetFilter(b.repo.scoped(filter)))
+	return b
+}
+
+// Execute runs the accumulated operations in order, stopping at the first failing one
+func (b *BulkBuilder[T]) Execute(ctx context.Context) (BulkResult, error) {
+	var result BulkResult
+	if b.err != nil {
+		return result, b.err
+	}
+	if len(b.writes) == 0 {
+		return result, nil
+	}
+	for _, save := range b.saves {
+		if err := b.prepare(ctx, save); err != nil {
+			return result, err
+		}
+	}
+	collection, err := b.repo.writeCollection(nil)
+	if err != nil {
+		return result, err
+	}
+	var res *mongo.BulkWriteResult
+	err = b.repo.execute(ctx, "Bulk", nil, func(ctx context.Context) (err error) {
+		res, err = collection.BulkWrite(ctx, b.writes, options.BulkWrite().SetOrdered(true))
+		return err
+	})
+	if res != nil {
+		result = BulkResult{
+			InsertedCount: res.InsertedCount,
+			MatchedCount:  res.MatchedCount,
+			ModifiedCount: res.ModifiedCount,
+			UpsertedCount: res.UpsertedCount,
+			DeletedCount:  res.DeletedCount,
+		}
+	}
+	if err != nil {
+		return result, wrapWriteError(err)
+	}
+	for _, save := range b.saves {
+		if err := afterSave(ctx, save.item); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// prepare runs the BeforeSave hook of an inserted or replaced item, then stamps & validates it
+// like Save, generating the id of inserted items when unset
+func (b *BulkBuilder[T]) prepare(ctx context.Context, save bulkSave[T]) error {
+	if err := beforeSave(ctx, save.item); err != nil {
+		return err
+	}
+	if err := b.repo.stampScope(save.item); err != nil {
+		return err
+	}
+	if err := validate(save.item); err != nil {
+		return err
+	}
+	if save.insert {
+		if _, err := b.repo.ensureId(save.item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBulkMixedOperations(t *testing.T) {
+	repo := setupTestRepo(t)
+	existing := []TestModel{
+		{Name: "Replace Me", Age: 20, CreatedAt: time.Now()},
+		{Name: "Update Me", Age: 30, CreatedAt: time.Now()},
+		{Name: "Delete Me", Age: 40, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), existing)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	replacement := existing[0]
+	replacement.Name = "Replaced"
+	result, err := repo.Bulk().
+		Insert(TestModel{Name: "Inserted", Age: 50, CreatedAt: time.Now()}).
+		Replace(replacement.ID, replacement).
+		Update(bson.M{"name": "Update Me"}, bson.M{"$set": bson.M{"age": 31}}).
+		Delete(bson.M{"name": "Delete Me"}).
+		Execute(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to execute bulk write: %v", err)
+	}
+	if result.InsertedCount != 1 || result.MatchedCount != 2 || result.ModifiedCount != 2 || result.DeletedCount != 1 {
+		t.Fatalf("Unexpected bulk result %+v", result)
+	}
+
+	items, err := repo.QueryRunner().
+		FilterB(bson.M{}).
+		Sort(`{"age":1}`).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query items: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items after bulk write, got %d", len(items))
+	}
+	if items[0].Name != "Replaced" || items[1].Name != "Update Me" || items[1].Age != 31 || items[2].Name != "Inserted" {
+		t.Fatalf("Unexpected collection state after bulk write: %+v", items)
+	}
+}
@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ChangeEvent[T any] struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   bson.M `bson:"documentKey"`
+	FullDocument  T      `bson:"fullDocument"`
+}
+
+// ChangeStream decodes the events of a driver change stream into ChangeEvent[T].
+type ChangeStream[T any] struct {
+	stream *mongo.ChangeStream
+	event  ChangeEvent[T]
+	err    error
+}
+
+// Watch opens a change stream on the collection, looking up the current version of updated
+// documents. Change streams are only available on replica sets & sharded clusters.
+func (r *MongoRepository[T]) Watch(ctx context.Context, pipeline []bson.M) (*ChangeStream[T], error) {
+	if pipeline == nil {
+		pipeline = []bson.M{}
+	}
+	var stream *mongo.ChangeStream
+	err := r.execute(ctx, "Watch", pipeline, func(ctx context.Context) (err error) {
+		stream, err = r.collection.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeStream[T]{stream: stream}, nil
+}
+
+func (s *ChangeStream[T]) Next(ctx context.Context) bool {
+	if s.err != nil || !s.stream.Next(ctx) {
+		return false
+	}
+	s.event = ChangeEvent[T]{}
+	if err := s.stream.Decode(&s.event); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+func (s *ChangeStream[T]) Event() ChangeEvent[T] {
+	return s.event
+}
+
+func (s *ChangeStream[T]) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.stream.Err()
+}
+
+func (s *ChangeStream[T]) Close() error {
+	return s.stream.Close(context.Background())
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestWatchInsert(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	var hello bson.M
+	err := repo.Database().RunCommand(context.TODO(), bson.M{"hello": 1}).Decode(&hello)
+	if err != nil {
+		t.Fatalf("Failed to run hello command: %v", err)
+	}
+	if _, ok := hello["setName"]; !ok {
+		t.Skip("change streams require a replica set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := repo.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to watch collection: %v", err)
+	}
+	defer stream.Close()
+
+	savedItem, err := repo.Save(context.TODO(), TestModel{Name: "Watched", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	if !stream.Next(ctx) {
+		t.Fatalf("Expected a change event, got error: %v", stream.Err())
+	}
+	event := stream.Event()
+	if event.OperationType != "insert" {
+		t.Fatalf("Expected insert event, got '%s'", event.OperationType)
+	}
+	if event.FullDocument.ID != savedItem.ID || event.FullDocument.Name != "Watched" {
+		t.Fatalf("Expected full document of saved item, got %+v", event.FullDocument)
+	}
+}
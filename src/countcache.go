@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// countCache holds the cached counts of a repository & of its clones, keyed by collection & scope
+type countCache struct {
+	mu      sync.Mutex
+	entries map[string]*countEntry
+}
+
+type countEntry struct {
+	mu        sync.Mutex
+	count     int64
+	expiresAt time.Time
+}
+
+// CachedCountAll returns the result of CountAll, reusing the last count of the repository until
+// ttl has passed since it was fetched. Concurrent callers wait for a single refresh. Counts are
+// kept per collection & scope, and are dropped by the writes of the repository & of its copies,
+// though not by writes made through other repositories. Repositories not built by
+// NewMongoRepository have no cache & count every time.
+func (r *MongoRepository[T]) CachedCountAll(ctx context.Context, ttl time.Duration) (int64, error) {
+	entry := r.countCache.entry(r.countKey())
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if time.Now().Before(entry.expiresAt) {
+		return entry.count, nil
+	}
+	count, err := r.countAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	entry.count = count
+	entry.expiresAt = time.Now().Add(ttl)
+	return count, nil
+}
+
+// invalidateCounts drops every cached count, as a write may change the count of any scope
+func (r *MongoRepository[T]) invalidateCounts() {
+	if r.countCache == nil {
+		return
+	}
+	r.countCache.mu.Lock()
+	defer r.countCache.mu.Unlock()
+	r.countCache.entries = nil
+}
+
+// entry returns the cached count of key, or an expired one that is not kept when c is nil
+func (c *countCache) entry(key string) *countEntry {
+	if c == nil {
+		return &countEntry{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*countEntry{}
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &countEntry{}
+		c.entries[key] = entry
+	}
+	return entry
+}
+
+// countKey identifies the documents counted by the repository, its scope keys being sorted so
+// that equal scopes share a key
+func (r *MongoRepository[T]) countKey() string {
+	scope := make(bson.D, 0, len(r.scope))
+	for key, value := range r.scope {
+		scope = append(scope, bson.E{Key: key, Value: value})
+	}
+	sort.Slice(scope, func(i, j int) bool { return scope[i].Key < scope[j].Key })
+	scopeKey, err := bson.MarshalExtJSON(scope, true, false)
+	if err != nil {
+		// values the encoder rejects fall back to their printed form
+		scopeKey = []byte(fmt.Sprint(scope))
+	}
+	return r.collection.Database().Name() + "." + r.collection.Name() + " " + string(scopeKey)
+}
@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupCountingRepo returns a repository whose client counts the count queries it sends,
+// CountDocuments running as an aggregate command
+func setupCountingRepo(t *testing.T) (*MongoRepository[TestModel], *int32) {
+	var calls int32
+	monitor := &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			if e.CommandName == "aggregate" {
+				atomic.AddInt32(&calls, 1)
+			}
+		},
+	}
+	opts := options.Client().ApplyURI("mongodb://localhost:27017/testdb").SetMonitor(monitor)
+	client, err := mongo.Connect(context.TODO(), opts)
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	collection := client.Database("testdb").Collection("countcache")
+	if err := collection.Drop(context.TODO()); err != nil {
+		t.Fatalf("Failed to drop collection: %v", err)
+	}
+	repo, err := NewMongoRepository[TestModel](collection)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	return repo, &calls
+}
+
+func TestCachedCountAll(t *testing.T) {
+	repo, calls := setupCountingRepo(t)
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Cached", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		count, err := repo.CachedCountAll(context.TODO(), time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to count items: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected count to be 1, got %d", count)
+		}
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Fatalf("Expected 1 count query within the TTL, got %d", n)
+	}
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Counted", Age: 40, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	count, err := repo.CachedCountAll(context.TODO(), time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 2 || atomic.LoadInt32(calls) != 2 {
+		t.Fatalf("Expected a save to invalidate the cached count, got %d", count)
+	}
+
+	if _, err := repo.DeleteAll(context.TODO()); err != nil {
+		t.Fatalf("Failed to delete items: %v", err)
+	}
+	count, err = repo.CachedCountAll(context.TODO(), time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected a delete to invalidate the cached count, got %d", count)
+	}
+}
+
+func TestCachedCountAllPerScope(t *testing.T) {
+	repo, _ := setupCountingRepo(t)
+	_, err := repo.Collection().InsertMany(context.TODO(), []interface{}{
+		bson.M{"name": "Tenant A 1", "tenant": "a"},
+		bson.M{"name": "Tenant A 2", "tenant": "a"},
+		bson.M{"name": "Tenant B", "tenant": "b"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert documents: %v", err)
+	}
+
+	a, err := repo.WithScope(bson.M{"tenant": "a"}).CachedCountAll(context.TODO(), time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to count tenant a: %v", err)
+	}
+	b, err := repo.WithScope(bson.M{"tenant": "b"}).CachedCountAll(context.TODO(), time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to count tenant b: %v", err)
+	}
+	all, err := repo.CachedCountAll(context.TODO(), time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to count all: %v", err)
+	}
+	if a != 2 || b != 1 || all != 3 {
+		t.Fatalf("Expected counts of 2, 1 & 3 per scope, got %d, %d & %d", a, b, all)
+	}
+}
+
+func TestCountCacheEntries(t *testing.T) {
+	repo := &MongoRepository[TestModel]{countCache: &countCache{}}
+
+	first := repo.countCache.entry("testdb.items {}")
+	if repo.countCache.entry("testdb.items {}") != first {
+		t.Fatalf("Expected the same key to share an entry")
+	}
+	if repo.countCache.entry(`testdb.items {"tenant":"a"}`) == first {
+		t.Fatalf("Expected another scope to get its own entry")
+	}
+	if repo.WithScope(bson.M{"tenant": "a"}).countCache != repo.countCache {
+		t.Fatalf("Expected clones to share the count cache")
+	}
+	repo.invalidateCounts()
+	if repo.countCache.entry("testdb.items {}") == first {
+		t.Fatalf("Expected invalidation to drop the entries")
+	}
+}
+
+func TestCountCacheMissing(t *testing.T) {
+	repo := &MongoRepository[TestModel]{}
+	repo.invalidateCounts()
+	if repo.countCache.entry("testdb.items {}") == repo.countCache.entry("testdb.items {}") {
+		t.Fatalf("Expected a repository without a cache not to keep entries")
+	}
+}
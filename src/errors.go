@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrDuplicateKey = errors.New("duplicate key")
+	ErrMissingId    = errors.New("id is not set")
+	ErrNotFound     = errors.New("document not found")
+	ErrInvalidID    = errors.New("invalid id")
+	// ErrDryRun is returned along with the number of documents a dry run would have affected
+	ErrDryRun = errors.New("dry run, no documents were changed")
+)
+
+const (
+	duplicateKeyCode          = 11000
+	indexOptionsConflictCode  = 85
+	indexKeySpecsConflictCode = 86
+)
+
+func IsDuplicateKeyError(err error) bool {
+	if errors.Is(err, ErrDuplicateKey) {
+		return true
+	}
+
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, we := range writeErr.WriteErrors {
+			if we.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) {
+		for _, we := range bulkErr.WriteErrors {
+			if we.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == duplicateKeyCode
+	}
+	return false
+}
+
+func wrapWriteError(err error) error {
+	if errors.Is(err, mongo.ErrUnacknowledgedWrite) {
+		return nil
+	}
+	if err != nil && IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %w", ErrDuplicateKey, err)
+	}
+	return err
+}
+
+// isIndexConflict reports whether an index could not be created because an index with the
+// same name or keys but different options already exists
+func isIndexConflict(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(indexOptionsConflictCode) || serverErr.HasErrorCode(indexKeySpecsConflictCode))
+}
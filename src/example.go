@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FindByExample finds the documents equal to example on each of its non zero fields, fields of
+// nested structs being matched on their dotted path. As zero values are skipped, an example
+// can't match fields such as false booleans or zero numbers.
+func (r *MongoRepository[T]) FindByExample(ctx context.Context, example T) ([]T, error) {
+	filter := bson.M{}
+	exampleFilter(reflect.ValueOf(example), "", filter)
+	return r.QueryRunner().Context(ctx).FilterB(filter).QueryMany()
+}
+
+// FindByField finds the documents whose fieldName equals value. fieldName is the bson name of a
+// field of T, or a dotted path into its nested structs, unknown fields being an error rather
+// than matching nothing.
+func (r *MongoRepository[T]) FindByField(ctx context.Context, fieldName string, value interface{}) ([]T, error) {
+	if err := checkFieldPath[T](fieldName); err != nil {
+		return nil, err
+	}
+	return r.QueryRunner().Context(ctx).FilterB(bson.M{fieldName: value}).QueryMany()
+}
+
+// FindOneByField is like FindByField but returns a single document, or ErrNotFound
+func (r *MongoRepository[T]) FindOneByField(ctx context.Context, fieldName string, value interface{}) (T, error) {
+	if err := checkFieldPath[T](fieldName); err != nil {
+		var zero T
+		return zero, err
+	}
+	return r.QueryRunner().Context(ctx).FilterB(bson.M{fieldName: value}).First()
+}
+
+func checkFieldPath[T any](path string) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for _, name := range strings.Split(path, ".") {
+		nested, ok := nestedStructType(t)
+		if !ok {
+			return fmt.Errorf("unknown field %s of %s", path, reflect.TypeOf((*T)(nil)).Elem())
+		}
+		if t, ok = fieldTypeByBsonName(nested, name); !ok {
+			return fmt.Errorf("unknown field %s of %s", path, reflect.TypeOf((*T)(nil)).Elem())
+		}
+	}
+	return nil
+}
+
+func exampleFilter(v reflect.Value, prefix string, filter bson.M) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		if isIgnored(field) || value.IsZero() {
+			continue
+		}
+		fieldName := prefix + getFieldName(field)
+		if _, ok := nestedStructType(field.Type); ok {
+			nestedPrefix := fieldName + "."
+			if isInline(field) {
+				nestedPrefix = prefix
+			}
+			exampleFilter(reflect.Indirect(value), nestedPrefix, filter)
+			continue
+		}
+		filter[fieldName] = value.Interface()
+	}
+}
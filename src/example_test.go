@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFindByExample(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Example", Age: 20, CreatedAt: time.Now()},
+		{Name: "Other", Age: 20, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	found, err := repo.FindByExample(context.TODO(), TestModel{Name: "Example"})
+	if err != nil {
+		t.Fatalf("Failed to find by example: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "Example" {
+		t.Fatalf("Expected only 'Example' to match, got %v", found)
+	}
+}
+
+func TestExampleFilterNested(t *testing.T) {
+	filter := bson.M{}
+	exampleFilter(reflect.ValueOf(NestedIndexModel{Address: Address{City: "Paris"}}), "", filter)
+
+	expected := bson.M{"address.city": "Paris"}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, filter)
+	}
+}
+
+func TestFindByField(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "By Field", Age: 20, CreatedAt: time.Now()},
+		{Name: "Other", Age: 30, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	found, err := repo.FindByField(context.TODO(), "name", "By Field")
+	if err != nil {
+		t.Fatalf("Failed to find by field: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != items[0].ID {
+		t.Fatalf("Expected only 'By Field' to match, got %v", found)
+	}
+
+	one, err := repo.FindOneByField(context.TODO(), "age", 30)
+	if err != nil {
+		t.Fatalf("Failed to find one by field: %v", err)
+	}
+	if one.Name != "Other" {
+		t.Fatalf("Expected 'Other', got '%s'", one.Name)
+	}
+	if _, err := repo.FindOneByField(context.TODO(), "age", 40); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCheckFieldPath(t *testing.T) {
+	if err := checkFieldPath[TestModel]("created_at"); err != nil {
+		t.Fatalf("Expected created_at to be a field, got %v", err)
+	}
+	if err := checkFieldPath[NestedIndexModel]("address.city"); err != nil {
+		t.Fatalf("Expected address.city to be a field, got %v", err)
+	}
+	for _, path := range []string{"Name", "unknown", "name.first", "address.unknown"} {
+		if err := checkFieldPath[NestedIndexModel](path); err == nil {
+			t.Fatalf("Expected unknown field %s to be rejected", path)
+		}
+	}
+}
+
+func TestExampleFilterSkipsIgnoredFields(t *testing.T) {
+	type cachedModel struct {
+		Name    string `bson:"name"`
+		Display string `bson:"-"`
+		secret  string
+	}
+	filter := bson.M{}
+	exampleFilter(reflect.ValueOf(cachedModel{Name: "Example", Display: "shown", secret: "hidden"}), "", filter)
+
+	expected := bson.M{"name": "Example"}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, filter)
+	}
+
+	if _, ok := fieldByBsonName(reflect.ValueOf(&cachedModel{}).Elem(), "-"); ok {
+		t.Fatalf("Expected a field tagged bson:\"-\" not to be found")
+	}
+	if _, ok := fieldByBsonName(reflect.ValueOf(&cachedModel{}).Elem(), "secret"); ok {
+		t.Fatalf("Expected an unexported field not to be found")
+	}
+}
+
+func TestExampleFilterUntaggedFields(t *testing.T) {
+	type looseModel struct {
+		Name  string
+		Age   int    `bson:",omitempty"`
+		Email string `bson:"mail,omitempty"`
+	}
+	filter := bson.M{}
+	exampleFilter(reflect.ValueOf(looseModel{Name: "x", Age: 3, Email: "x@example.com"}), "", filter)
+
+	expected := bson.M{"name": "x", "age": 3, "mail": "x@example.com"}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, filter)
+	}
+}
+
+type EventModel struct {
+	ID   primitive.ObjectID  `bson:"_id,omitempty"`
+	Name string              `bson:"name"`
+	At   primitive.Timestamp `bson:"at"`
+}
+
+func TestFindByExampleTimestamp(t *testing.T) {
+	repo, err := NewMongoRepository[EventModel](setupTestCollection(t, "events"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	items := []EventModel{
+		{Name: "First", At: primitive.Timestamp{T: 100, I: 1}},
+		{Name: "Second", At: primitive.Timestamp{T: 200, I: 1}},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	filter := bson.M{}
+	exampleFilter(reflect.ValueOf(EventModel{At: primitive.Timestamp{T: 200, I: 1}}), "", filter)
+	expected := bson.M{"at": primitive.Timestamp{T: 200, I: 1}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, filter)
+	}
+
+	found, err := repo.FindByExample(context.TODO(), EventModel{At: primitive.Timestamp{T: 200, I: 1}})
+	if err != nil {
+		t.Fatalf("Failed to find by example: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "Second" {
+		t.Fatalf("Expected only 'Second' to match, got %v", found)
+	}
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// WithDefaultTimeout returns a copy of the repository bounding every operation by timeout
+// when its context has no deadline. Contexts that already carry a deadline are left untouched.
+// The timeout covers reading every batch of the results, except for QueryChan & AggregateStream,
+// which stream for as long as their own context allows.
+func (r *MongoRepository[T]) WithDefaultTimeout(timeout time.Duration) *MongoRepository[T] {
+	clone := r.clone()
+	clone.defaultTimeout = timeout
+	return clone
+}
+
+// writeOps are the operations changing documents, which drop the cached counts of the repository
+var writeOps = map[string]bool{
+	"ArchiveById": true, "Backfill": true, "Bulk": true, "Delete": true, "DeleteAll": true,
+	"DeleteById": true, "DeleteInBatches": true, "Drop": true, "InsertMany": true,
+	"RenameField": true, "Save": true, "SaveAll": true, "SaveAllResult": true, "SaveFields": true,
+	"Unset": true, "UpdateByIdAndReturn": true, "Upsert": true, "UpsertByFilter": true,
+}
+
+// execute runs a single driver operation, applying the timeout, tracing, retry & logging configuration of the repository
+func (r *MongoRepository[T]) execute(ctx context.Context, op string, filter interface{}, operation func(ctx context.Context) error) (err error) {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && r.defaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.defaultTimeout)
+		defer cancel()
+	}
+	if r.tracer != nil {
+		spanCtx, span := r.startSpan(ctx, op, filter)
+		defer func() { endSpan(span, err) }()
+		ctx = spanCtx
+	}
+	if writeOps[op] {
+		// a failed write may still have changed some documents
+		defer r.invalidateCounts()
+	}
+	if r.logger != nil || r.slowQueryEnabled() {
+		start := time.Now()
+		defer func() { r.logQuery(op, filter, time.Since(start), err) }()
+	}
+	return r.retry(ctx, !nonIdempotentOps[op], operation)
+}
+
+func (r *MongoRepository[T]) slowQueryEnabled() bool {
+	return r.slowQueryThreshold > 0 && r.onSlowQuery != nil
+}
+
+func (r *MongoRepository[T]) logQuery(op string, filter interface{}, duration time.Duration, err error) {
+	if r.logger != nil {
+		r.logger.LogQuery(op, filter, duration, err)
+	}
+	if r.slowQueryEnabled() && duration > r.slowQueryThreshold {
+		r.onSlowQuery(op, filter, duration)
+	}
+}
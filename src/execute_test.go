@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDefaultTimeout(t *testing.T) {
+	repo := (&MongoRepository[TestModel]{}).WithDefaultTimeout(10 * time.Millisecond)
+
+	err := repo.execute(context.Background(), "Slow", nil, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestDefaultTimeoutKeepsExistingDeadline(t *testing.T) {
+	repo := (&MongoRepository[TestModel]{}).WithDefaultTimeout(time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	expected, _ := ctx.Deadline()
+
+	err := repo.execute(ctx, "Deadline", nil, func(ctx context.Context) error {
+		if deadline, _ := ctx.Deadline(); !deadline.Equal(expected) {
+			return errors.New("deadline was replaced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected existing deadline to be kept, got %v", err)
+	}
+}
+
+func TestDefaultTimeoutSlowQuery(t *testing.T) {
+	repo := setupTestRepo(t)
+	_, err := repo.Save(context.TODO(), TestModel{Name: "Slow", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save test item: %v", err)
+	}
+
+	_, err = repo.WithDefaultTimeout(50 * time.Millisecond).QueryRunner().
+		FilterB(bson.M{"$where": "sleep(500) || true"}).
+		QueryMany()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestDefaultTimeoutCoversGetMore(t *testing.T) {
+	repo := setupTestRepo(t)
+	for i := 0; i < 4; i++ {
+		if _, err := repo.Save(context.TODO(), TestModel{Name: "Batched", Age: 30, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to save test item: %v", err)
+		}
+	}
+
+	// the first batch returns within the timeout, the getMores fetching the others do not
+	_, err := repo.WithDefaultTimeout(300 * time.Millisecond).QueryRunner().
+		FilterB(bson.M{"$where": "sleep(100) || true"}).
+		BatchSize(1).
+		QueryMany()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded while reading later batches, got %v", err)
+	}
+}
+
+func TestMaxTime(t *testing.T) {
+	repo := setupTestRepo(t)
+	_, err := repo.Save(context.TODO(), TestModel{Name: "Slow", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save test item: %v", err)
+	}
+
+	_, err = repo.QueryRunner().
+		FilterB(bson.M{"$where": "sleep(500) || true"}).
+		MaxTime(10 * time.Millisecond).
+		QueryMany()
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) || !serverErr.HasErrorCode(50) {
+		t.Fatalf("Expected MaxTimeMSExpired server error, got %v", err)
+	}
+}
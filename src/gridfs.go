@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSBucket stores large binary files in a GridFS bucket of the repository's database,
+// separately from the documents of the repository.
+type GridFSBucket struct {
+	database *mongo.Database
+	options  []*options.BucketOptions
+}
+
+func (r *MongoRepository[T]) GridFSBucket(opts ...*options.BucketOptions) *GridFSBucket {
+	return &GridFSBucket{database: r.collection.Database(), options: opts}
+}
+
+// bucket creates a driver bucket per call, as its deadlines are shared by every operation on it
+func (b *GridFSBucket) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(b.database, b.options...)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func (b *GridFSBucket) UploadFile(ctx context.Context, name string, r io.Reader) (primitive.ObjectID, error) {
+	bucket, err := b.bucket(ctx)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return bucket.UploadFromStream(name, r)
+}
+
+func (b *GridFSBucket) DownloadFile(ctx context.Context, id primitive.ObjectID, w io.Writer) error {
+	bucket, err := b.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = bucket.DownloadToStream(id, w)
+	return err
+}
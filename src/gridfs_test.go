@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestGridFSRoundTrip(t *testing.T) {
+	repo := setupTestRepo(t)
+	bucket := repo.GridFSBucket()
+
+	content := bytes.Repeat([]byte("attachment "), 50000)
+	id, err := bucket.UploadFile(context.TODO(), "attachment.txt", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+	if id.IsZero() {
+		t.Fatalf("Expected non-zero ID for uploaded file")
+	}
+
+	var downloaded bytes.Buffer
+	if err := bucket.DownloadFile(context.TODO(), id, &downloaded); err != nil {
+		t.Fatalf("Failed to download file: %v", err)
+	}
+	if !bytes.Equal(downloaded.Bytes(), content) {
+		t.Fatalf("Expected downloaded content to match uploaded content, got %d bytes", downloaded.Len())
+	}
+}
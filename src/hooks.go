@@ -0,0 +1,36 @@
+package repo
+
+import "context"
+
+type BeforeSaver interface {
+	BeforeSave(ctx context.Context) error
+}
+
+type AfterSaver interface {
+	AfterSave(ctx context.Context) error
+}
+
+// hooks are looked up on *T so both value and pointer receivers are supported
+func beforeSave[T any](ctx context.Context, item *T) error {
+	if hook, ok := any(item).(BeforeSaver); ok {
+		return hook.BeforeSave(ctx)
+	}
+	return nil
+}
+
+func afterSave[T any](ctx context.Context, item *T) error {
+	if hook, ok := any(item).(AfterSaver); ok {
+		return hook.AfterSave(ctx)
+	}
+	return nil
+}
+
+// afterSaveAll runs the AfterSave hook of every item, stopping at the first error
+func afterSaveAll[T any](ctx context.Context, items []T) error {
+	for i := range items {
+		if err := afterSave(ctx, &items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type HookModel struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Name  string             `bson:"name"`
+	Saved bool               `bson:"-"`
+}
+
+var errEmptyName = errors.New("name is required")
+
+func (m *HookModel) BeforeSave(ctx context.Context) error {
+	if m.Name == "" {
+		return errEmptyName
+	}
+	return nil
+}
+
+func (m *HookModel) AfterSave(ctx context.Context) error {
+	m.Saved = true
+	return nil
+}
+
+func setupHookRepo(t *testing.T) *MongoRepository[HookModel] {
+	repo, err := NewMongoRepository[HookModel](setupTestCollection(t, "hooks"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	return repo
+}
+
+func TestSaveHooks(t *testing.T) {
+	repo := setupHookRepo(t)
+
+	savedItem, err := repo.Save(context.TODO(), HookModel{Name: "Hooked"})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	if !savedItem.Saved {
+		t.Fatalf("Expected AfterSave hook to run")
+	}
+
+	_, err = repo.Save(context.TODO(), HookModel{})
+	if !errors.Is(err, errEmptyName) {
+		t.Fatalf("Expected BeforeSave error, got %v", err)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected rejected item not to be saved, but db count is %d", count)
+	}
+}
+
+func TestSaveAllHooks(t *testing.T) {
+	repo := setupHookRepo(t)
+
+	_, err := repo.SaveAll(context.TODO(), []HookModel{{Name: "Valid"}, {}})
+	if !errors.Is(err, errEmptyName) {
+		t.Fatalf("Expected BeforeSave error, got %v", err)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no items to be saved, but db count is %d", count)
+	}
+
+	savedItems, err := repo.SaveAll(context.TODO(), []HookModel{{Name: "Valid 1"}, {Name: "Valid 2"}})
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+	for _, item := range savedItems {
+		if !item.Saved {
+			t.Fatalf("Expected AfterSave hook to run for every item")
+		}
+	}
+}
+
+func TestBulkHooks(t *testing.T) {
+	repo := setupHookRepo(t)
+
+	_, err := repo.Bulk().Insert(HookModel{Name: "Valid"}).Insert(HookModel{}).Execute(context.TODO())
+	if !errors.Is(err, errEmptyName) {
+		t.Fatalf("Expected BeforeSave error, got %v", err)
+	}
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no items to be saved, but db count is %d", count)
+	}
+
+	result, err := repo.Bulk().Insert(HookModel{Name: "Bulk 1"}).Insert(HookModel{Name: "Bulk 2"}).Execute(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to execute bulk write: %v", err)
+	}
+	if result.InsertedCount != 2 {
+		t.Fatalf("Expected 2 items to be inserted, got %d", result.InsertedCount)
+	}
+}
+
+func TestInsertManyHooks(t *testing.T) {
+	repo := setupHookRepo(t)
+
+	_, err := repo.InsertMany(context.TODO(), []HookModel{{Name: "Valid"}, {}}, true)
+	if !errors.Is(err, errEmptyName) {
+		t.Fatalf("Expected BeforeSave error, got %v", err)
+	}
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no items to be inserted, but db count is %d", count)
+	}
+
+	inserted, err := repo.InsertMany(context.TODO(), []HookModel{{Name: "Insert 1"}, {Name: "Insert 2"}}, true)
+	if err != nil {
+		t.Fatalf("Failed to insert items: %v", err)
+	}
+	for _, item := range inserted {
+		if !item.Saved {
+			t.Fatalf("Expected AfterSave hook to run for every item")
+		}
+	}
+}
+
+func TestInMemoryInsertManyHooks(t *testing.T) {
+	repo, err := NewInMemoryRepository[HookModel]()
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	if _, err := repo.InsertMany(context.TODO(), []HookModel{{Name: "Valid"}, {}}, true); !errors.Is(err, errEmptyName) {
+		t.Fatalf("Expected BeforeSave error, got %v", err)
+	}
+	if count, _ := repo.CountAll(context.TODO()); count != 0 {
+		t.Fatalf("Expected no items to be inserted, but count is %d", count)
+	}
+
+	inserted, err := repo.InsertMany(context.TODO(), []HookModel{{Name: "Insert 1"}, {Name: "Insert 2"}}, true)
+	if err != nil {
+		t.Fatalf("Failed to insert items: %v", err)
+	}
+	for _, item := range inserted {
+		if !item.Saved {
+			t.Fatalf("Expected AfterSave hook to run for every item")
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	errAggregationUnsupported = errors.New("aggregation is not supported by InMemoryRepository")
+	errExplainUnsupported     = errors.New("explain is not supported by InMemoryRepository")
+)
+
+// InMemoryRepository is a map backed Repository meant for unit tests. Queries
+// only support top level equality filters.
+type InMemoryRepository[T any] struct {
+	mu           sync.RWMutex
+	items        map[interface{}]T
+	idFieldIndex int
+}
+
+var _ Repository[any] = (*InMemoryRepository[any])(nil)
+
+func NewInMemoryRepository[T any]() (*InMemoryRepository[T], error) {
+	if err := checkModelType[T](); err != nil {
+		return nil, err
+	}
+
+	index, err := findIdFieldIndex(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &InMemoryRepository[T]{
+		items:        map[interface{}]T{},
+		idFieldIndex: index,
+	}, nil
+}
+
+func (r *InMemoryRepository[T]) id(item *T) interface{} {
+	return getId(item, r.idFieldIndex)
+}
+
+func (r *InMemoryRepository[T]) sorted() []T {
+	results := make([]T, 0, len(r.items))
+	for _, item := range r.items {
+		results = append(results, item)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return fmt.Sprint(r.id(&results[i])) < fmt.Sprint(r.id(&results[j]))
+	})
+	return results
+}
+
+func (r *InMemoryRepository[T]) QueryRunner() *QueryBuilder[T] {
+	return &QueryBuilder[T]{context: context.TODO(), repo: r}
+}
+
+func (r *InMemoryRepository[T]) FindAll(ctx context.Context) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sorted(), nil
+}
+
+func (r *InMemoryRepository[T]) FindById(ctx context.Context, id interface{}) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	item, ok := r.items[id]
+	if !ok {
+		return item, mongo.ErrNoDocuments
+	}
+	return item, nil
+}
+
+func (r *InMemoryRepository[T]) FindByIds(ctx context.Context, ids []primitive.ObjectID) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var results []T
+	for _, id := range ids {
+		if item, ok := r.items[id]; ok {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+func (r *InMemoryRepository[T]) ExistsById(ctx context.Context, id interface{}) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.items[id]
+	return ok, nil
+}
+
+func (r *InMemoryRepository[T]) CountAll(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.items)), nil
+}
+
+func (r *InMemoryRepository[T]) EstimatedCount(ctx context.Context) (int64, error) {
+	return r.CountAll(ctx)
+}
+
+func (r *InMemoryRepository[T]) Count(ctx context.Context, query *QueryBuilder[T]) (int64, error) {
+	results, err := r.filter(query.filterMap())
+	return int64(len(results)), err
+}
+
+func (r *InMemoryRepository[T]) Save(ctx context.Context, item T) (T, error) {
+	if err := beforeSave(ctx, &item); err != nil {
+		return item, err
+	}
+	if err := validate(&item); err != nil {
+		return item, err
+	}
+	id, err := ensureId(&item, r.idFieldIndex)
+	if err != nil {
+		return item, err
+	}
+
+	r.mu.Lock()
+	r.items[id] = item
+	r.mu.Unlock()
+
+	if err := afterSave(ctx, &item); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+func (r *InMemoryRepository[T]) SaveAll(ctx context.Context, items []T) ([]T, error) {
+	for i := range items {
+		saved, err := r.Save(ctx, items[i])
+		items[i] = saved
+		if err != nil {
+			return items, err
+		}
+	}
+	return items, nil
+}
+
+func (r *InMemoryRepository[T]) InsertMany(ctx context.Context, items []T, ordered bool) ([]T, error) {
+	ids := make([]interface{}, len(items))
+	for i := range items {
+		if err := beforeSave(ctx, &items[i]); err != nil {
+			return nil, err
+		}
+		if err := validate(&items[i]); err != nil {
+			return nil, err
+		}
+		id, err := ensureId(&items[i], r.idFieldIndex)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	r.mu.Lock()
+	var inserted []T
+	var failed int
+	for i, id := range ids {
+		if _, ok := r.items[id]; ok {
+			failed++
+			if ordered {
+				break
+			}
+			continue
+		}
+		r.items[id] = items[i]
+		inserted = append(inserted, items[i])
+	}
+	r.mu.Unlock()
+
+	var err error
+	if failed > 0 {
+		err = fmt.Errorf("failed to insert items: %w", ErrDuplicateKey)
+	}
+	// hooks run outside the lock, as they may use the repository
+	return inserted, errors.Join(err, afterSaveAll(ctx, inserted))
+}
+
+func (r *InMemoryRepository[T]) DeleteById(ctx context.Context, id interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	return nil
+}
+
+func (r *InMemoryRepository[T]) DeleteAll(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := int64(len(r.items))
+	r.items = map[interface{}]T{}
+	return count, nil
+}
+
+func (r *InMemoryRepository[T]) Delete(ctx context.Context, query *QueryBuilder[T]) (int64, error) {
+	results, err := r.filter(query.filterMap())
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range results {
+		delete(r.items, r.id(&results[i]))
+	}
+	return int64(len(results)), nil
+}
+
+// Unset resets the given fields of the matching items to their zero value
+func (r *InMemoryRepository[T]) Unset(ctx context.Context, query *QueryBuilder[T], fields ...string) (int64, error) {
+	results, err := r.filter(query.filterMap())
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var modified int64
+	for i := range results {
+		item := reflect.ValueOf(&results[i]).Elem()
+		changed := false
+		for _, name := range fields {
+			if field, ok := fieldByBsonName(item, name); ok && !field.IsZero() {
+				field.Set(reflect.Zero(field.Type()))
+				changed = true
+			}
+		}
+		if changed {
+			r.items[r.id(&results[i])] = results[i]
+			modified++
+		}
+	}
+	return modified, nil
+}
+
+func (r *InMemoryRepository[T]) QueryOne(ctx context.Context, query *QueryBuilder[T]) (T, error) {
+	var result T
+	results, err := r.filter(query.filterMap())
+	if err != nil {
+		return result, err
+	}
+	if len(results) == 0 {
+		return result, mongo.ErrNoDocuments
+	}
+	return results[0], nil
+}
+
+func (r *InMemoryRepository[T]) QueryMany(ctx context.Context, query *QueryBuilder[T]) ([]T, error) {
+	results, err := r.filter(query.filterMap())
+	if err != nil {
+		return nil, err
+	}
+	if len(query.pageable) == 2 && query.pageable[1] > 0 {
+		start := min(query.pageable[0]*query.pageable[1], len(results))
+		end := min(start+query.pageable[1], len(results))
+		results = results[start:end]
+	}
+	return results, nil
+}
+
+func (r *InMemoryRepository[T]) QueryChan(ctx context.Context, query *QueryBuilder[T]) (<-chan T, <-chan error) {
+	results := make(chan T)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(results)
+
+		items, err := r.QueryMany(ctx, query)
+		if err != nil {
+			errs <- err
+			return
+		}
+		for _, item := range items {
+			select {
+			case results <- item:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return results, errs
+}
+
+func (r *InMemoryRepository[T]) Explain(ctx context.Context, query *QueryBuilder[T]) (bson.M, error) {
+	return nil, errExplainUnsupported
+}
+
+func (r *InMemoryRepository[T]) AggregateOne(ctx context.Context, pipeline []bson.M) (bson.M, error) {
+	return nil, errAggregationUnsupported
+}
+
+func (r *InMemoryRepository[T]) AggregateMultiple(ctx context.Context, pipeline []bson.M) ([]bson.M, error) {
+	return nil, errAggregationUnsupported
+}
+
+func (r *InMemoryRepository[T]) AggregateTyped(ctx context.Context, pipeline []bson.M) ([]T, error) {
+	return nil, errAggregationUnsupported
+}
+
+func (r *InMemoryRepository[T]) filter(filter bson.M) ([]T, error) {
+	// round trip the filter through bson so its values compare equal to the stored documents
+	normalized, err := toBsonM(filter)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range normalized {
+		if operator := findOperator(key, value); operator != "" {
+			return nil, fmt.Errorf("unsupported filter operator for InMemoryRepository: %s", operator)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var results []T
+	for _, item := range r.sorted() {
+		doc, err := toBsonM(item)
+		if err != nil {
+			return nil, err
+		}
+		if matchesEquality(doc, normalized) {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+func findOperator(key string, value interface{}) string {
+	if strings.HasPrefix(key, "$") {
+		return key
+	}
+	if nested, ok := value.(bson.M); ok {
+		for nestedKey := range nested {
+			if strings.HasPrefix(nestedKey, "$") {
+				return nestedKey
+			}
+		}
+	}
+	return ""
+}
+
+func matchesEquality(doc bson.M, filter bson.M) bool {
+	for key, value := range filter {
+		if !reflect.DeepEqual(doc[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+func toBsonM(value interface{}) (bson.M, error) {
+	doc := bson.M{}
+	if value == nil {
+		return doc, nil
+	}
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
@@ -0,0 +1,239 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupInMemoryRepo(t *testing.T) *InMemoryRepository[TestModel] {
+	repo, err := NewInMemoryRepository[TestModel]()
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	return repo
+}
+
+func TestInMemorySave(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+
+	newItem := TestModel{Name: "John Doe", Age: 30, CreatedAt: time.Now()}
+	savedItem, err := repo.Save(context.TODO(), newItem)
+	if err != nil {
+		t.Fatalf("Failed to save new item: %v", err)
+	}
+	if savedItem.ID.IsZero() {
+		t.Fatalf("Expected non-zero ID for saved item")
+	}
+
+	savedItem.Name = "Jane Doe"
+	updatedItem, err := repo.Save(context.TODO(), savedItem)
+	if err != nil {
+		t.Fatalf("Failed to update item: %v", err)
+	}
+	if updatedItem.Name != "Jane Doe" {
+		t.Fatalf("Expected updated name to be 'Jane Doe', got '%s'", updatedItem.Name)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items after saving: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected db count to be 1 got %d", count)
+	}
+}
+
+func TestInMemoryFindById(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+
+	savedItem, err := repo.Save(context.TODO(), TestModel{Name: "Test User", Age: 25, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save test item: %v", err)
+	}
+
+	foundItem, err := repo.FindById(context.TODO(), savedItem.ID)
+	if err != nil {
+		t.Fatalf("Failed to find item by ID: %v", err)
+	}
+	if foundItem.ID != savedItem.ID {
+		t.Fatalf("Expected found item ID to match saved item ID")
+	}
+}
+
+func TestInMemoryFindAll(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+	items := []TestModel{
+		{Name: "User 1", Age: 25, CreatedAt: time.Now()},
+		{Name: "User 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "User 3", Age: 35, CreatedAt: time.Now()},
+	}
+
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	foundItems, err := repo.FindAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to find all items: %v", err)
+	}
+	if len(foundItems) != len(items) {
+		t.Fatalf("Expected to find %d items, but found %d", len(items), len(foundItems))
+	}
+}
+
+func TestInMemoryDeleteById(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+
+	savedItem, err := repo.Save(context.TODO(), TestModel{Name: "Delete Test", Age: 50, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save test item: %v", err)
+	}
+
+	err = repo.DeleteById(context.TODO(), savedItem.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete item by ID: %v", err)
+	}
+
+	_, err = repo.FindById(context.TODO(), savedItem.ID)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("Expected item to be deleted, got %v", err)
+	}
+}
+
+func TestInMemoryQueryMany(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+	items := []TestModel{
+		{Name: "Query Many 1", Age: 25},
+		{Name: "Query Many 2", Age: 30},
+		{Name: "Query Many 3", Age: 30},
+	}
+
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	foundItems, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query many items: %v", err)
+	}
+	if len(foundItems) != 2 {
+		t.Fatalf("Expected to find 2 items, but found %d", len(foundItems))
+	}
+
+	foundItems, err = repo.QueryRunner().
+		FilterB(bson.M{"name": "Query Many 1"}).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query many items: %v", err)
+	}
+	if len(foundItems) != 1 || foundItems[0].Age != 25 {
+		t.Fatalf("Query results do not match expected values")
+	}
+
+	_, err = repo.QueryRunner().
+		Filter(`{"age":{ "$gte": 30 }}`).
+		QueryMany()
+	if err == nil {
+		t.Fatalf("Expected operator filters to be rejected")
+	}
+}
+
+func TestInMemoryQueryManyPtr(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+	items := []TestModel{
+		{Name: "Pointer 1", Age: 30},
+		{Name: "Pointer 2", Age: 30},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	found, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		QueryManyPtr()
+	if err != nil {
+		t.Fatalf("Failed to query item pointers: %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("Expected to find 2 items, but found %d", len(found))
+	}
+	for _, item := range found {
+		if item == nil {
+			t.Fatalf("Expected non nil item pointers")
+		}
+		item.Age++
+	}
+	for _, item := range found {
+		if item.Age != 31 {
+			t.Fatalf("Expected item to be modified in place, got age %d", item.Age)
+		}
+	}
+}
+
+func TestInMemoryQueryChanCancel(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+	for i := 0; i < 5; i++ {
+		if _, err := repo.Save(context.TODO(), TestModel{Name: "Stream", Age: i, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to save test item: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errs := repo.QueryRunner().
+		Filter(`{"name": ?1}`, "Stream").
+		QueryChan(ctx)
+
+	if _, ok := <-results; !ok {
+		t.Fatalf("Expected to receive an item before cancelling")
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the streaming goroutine to exit after cancellation")
+	}
+	for range results {
+	}
+}
+
+func TestInMemoryUnset(t *testing.T) {
+	repo := setupInMemoryRepo(t)
+	young, _ := repo.Save(context.TODO(), TestModel{Name: "Young", Age: 20, CreatedAt: time.Now()})
+	old, _ := repo.Save(context.TODO(), TestModel{Name: "Old", Age: 60, CreatedAt: time.Now()})
+
+	modified, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 60).
+		Unset("name")
+	if err != nil {
+		t.Fatalf("Failed to unset field: %v", err)
+	}
+	if modified != 1 {
+		t.Fatalf("Expected 1 item to be modified, got %d", modified)
+	}
+
+	found, _ := repo.FindById(context.TODO(), old.ID)
+	if found.Name != "" || found.Age != 60 {
+		t.Fatalf("Expected name to be removed from matching item, got %+v", found)
+	}
+	found, _ = repo.FindById(context.TODO(), young.ID)
+	if found.Name != "Young" {
+		t.Fatalf("Expected other items to be untouched, got %+v", found)
+	}
+
+	if _, err := repo.QueryRunner().Unset(); err == nil {
+		t.Fatalf("Expected an error when unsetting no fields")
+	}
+}
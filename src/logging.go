@@ -0,0 +1,25 @@
+package repo
+
+import "time"
+
+type Logger interface {
+	LogQuery(op string, filter interface{}, duration time.Duration, err error)
+}
+
+// WithLogger returns a copy of the repository reporting every operation to logger once it completes.
+func (r *MongoRepository[T]) WithLogger(logger Logger) *MongoRepository[T] {
+	clone := r.clone()
+	clone.logger = logger
+	return clone
+}
+
+type SlowQueryFunc func(op string, filter interface{}, duration time.Duration)
+
+// WithSlowQueryThreshold returns a copy of the repository calling onSlowQuery for every operation
+// taking longer than threshold. A zero threshold disables it.
+func (r *MongoRepository[T]) WithSlowQueryThreshold(threshold time.Duration, onSlowQuery SlowQueryFunc) *MongoRepository[T] {
+	clone := r.clone()
+	clone.slowQueryThreshold = threshold
+	clone.onSlowQuery = onSlowQuery
+	return clone
+}
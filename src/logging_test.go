@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type loggedQuery struct {
+	op     string
+	filter interface{}
+	err    error
+}
+
+type capturingLogger struct {
+	queries []loggedQuery
+}
+
+func (l *capturingLogger) LogQuery(op string, filter interface{}, duration time.Duration, err error) {
+	l.queries = append(l.queries, loggedQuery{op: op, filter: filter, err: err})
+}
+
+func TestLogger(t *testing.T) {
+	logger := &capturingLogger{}
+	repo := setupTestRepo(t).WithLogger(logger)
+
+	filter := bson.M{"age": bson.M{"$gte": 30}}
+	_, err := repo.QueryRunner().
+		FilterB(filter).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query many items: %v", err)
+	}
+
+	if len(logger.queries) != 1 {
+		t.Fatalf("Expected 1 logged query, but got %d", len(logger.queries))
+	}
+	logged := logger.queries[0]
+	if logged.op != "QueryMany" {
+		t.Fatalf("Expected logged op to be QueryMany, got %s", logged.op)
+	}
+	if !reflect.DeepEqual(logged.filter, filter) {
+		t.Fatalf("Expected logged filter %v, got %v", filter, logged.filter)
+	}
+	if logged.err != nil {
+		t.Fatalf("Expected no logged error, got %v", logged.err)
+	}
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	var slowOps []string
+	onSlowQuery := func(op string, filter interface{}, duration time.Duration) {
+		slowOps = append(slowOps, op)
+	}
+	repo := setupTestRepo(t).WithSlowQueryThreshold(time.Nanosecond, onSlowQuery)
+
+	_, err := repo.QueryRunner().
+		FilterB(bson.M{}).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query many items: %v", err)
+	}
+
+	if len(slowOps) != 1 || slowOps[0] != "QueryMany" {
+		t.Fatalf("Expected slow query callback for QueryMany, got %v", slowOps)
+	}
+
+	slowOps = nil
+	_, err = repo.WithSlowQueryThreshold(time.Hour, onSlowQuery).CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if len(slowOps) != 0 {
+		t.Fatalf("Expected no slow query callback under the threshold, got %v", slowOps)
+	}
+}
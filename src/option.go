@@ -0,0 +1,57 @@
+package repo
+
+// Option configures a repository at construction.
+type Option func(*constructOptions)
+
+type constructOptions struct {
+	logger            Logger
+	strictIndexes     bool
+	skipIndexCreation bool
+}
+
+// StrictIndexes makes construction fail when an index declared by tags conflicts with an
+// existing index, instead of logging the conflict & keeping the existing index.
+func StrictIndexes() Option {
+	return func(o *constructOptions) {
+		o.strictIndexes = true
+	}
+}
+
+// SkipIndexCreation skips creating the indexes & schema validator declared by tags, for callers
+// without the permission to change the collection, such as against read only replicas, or to
+// speed up tests.
+func SkipIndexCreation() Option {
+	return func(o *constructOptions) {
+		o.skipIndexCreation = true
+	}
+}
+
+// UseLogger sets the logger of the repository at construction, so that index conflicts are
+// logged along with the queries, like a later call to WithLogger.
+func UseLogger(logger Logger) Option {
+	return func(o *constructOptions) {
+		o.logger = logger
+	}
+}
+
+// RepoOptions holds the construction options of NewMongoRepositoryWithOptions, each field
+// matching the Option of the same name.
+type RepoOptions struct {
+	Logger            Logger
+	StrictIndexes     bool
+	SkipIndexCreation bool
+}
+
+func (o RepoOptions) options() []Option {
+	var opts []Option
+	if o.Logger != nil {
+		opts = append(opts, UseLogger(o.Logger))
+	}
+	if o.StrictIndexes {
+		opts = append(opts, StrictIndexes())
+	}
+	if o.SkipIndexCreation {
+		opts = append(opts, SkipIndexCreation())
+	}
+	return opts
+}
@@ -3,19 +3,41 @@ package repo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type QueryBuilder[T any] struct {
-	repo       *MongoRepository[T]
+	repo       Repository[T]
 	filter     bson.M
 	projection bson.M
 	sort       bson.D
 	context    context.Context
 	pageable   [2]int
+	batchSize  int
+
+	readPreference *readpref.ReadPref
+	readConcern    *readconcern.ReadConcern
+	maxStaleness   time.Duration
+
+	databaseName   string
+	collectionName string
+	strictFields   bool
+	dryRun         bool
+	hint           interface{}
+	maxTime        time.Duration
+	addFields      bson.M
+	orderedFilter  bson.D
 }
 
 func (q *QueryBuilder[T]) Filter(filter string, params ...interface{}) *QueryBuilder[T] {
@@ -24,11 +46,120 @@ func (q *QueryBuilder[T]) Filter(filter string, params ...interface{}) *QueryBui
 	if err != nil {
 		panic(err)
 	}
+	q.orderedFilter = nil
 	return q
 }
 
 func (q *QueryBuilder[T]) FilterB(filter bson.M) *QueryBuilder[T] {
 	q.filter = filter
+	q.orderedFilter = nil
+	return q
+}
+
+// FilterD sets an ordered filter, passed to the driver as is, for operators & range queries whose
+// key order matters. Conditions added afterwards, such as with In, are appended to a copy of it.
+func (q *QueryBuilder[T]) FilterD(filter bson.D) *QueryBuilder[T] {
+	// copied so appended conditions never write into the caller's backing array
+	q.orderedFilter = append(bson.D{}, filter...)
+	q.filter = nil
+	return q
+}
+
+// filterMap returns the filter as a map, losing the order of an ordered filter
+func (q *QueryBuilder[T]) filterMap() bson.M {
+	if q.orderedFilter == nil {
+		return q.filter
+	}
+	filter := make(bson.M, len(q.orderedFilter))
+	for _, e := range q.orderedFilter {
+		filter[e.Key] = e.Value
+	}
+	return filter
+}
+
+// setField sets the condition on field, replacing any previous condition on it
+func (q *QueryBuilder[T]) setField(field string, condition interface{}) *QueryBuilder[T] {
+	if q.orderedFilter != nil {
+		for i := range q.orderedFilter {
+			if q.orderedFilter[i].Key == field {
+				q.orderedFilter[i].Value = condition
+				return q
+			}
+		}
+		q.orderedFilter = append(q.orderedFilter, bson.E{Key: field, Value: condition})
+		return q
+	}
+	if q.filter == nil {
+		q.filter = bson.M{}
+	}
+	q.filter[field] = condition
+	return q
+}
+
+// Regex adds a {field: {$regex: pattern, $options: opts}} condition to the filter, opts such as "i"
+// making the match case insensitive
+func (q *QueryBuilder[T]) Regex(field, pattern string, opts string) *QueryBuilder[T] {
+	return q.setField(field, bson.M{"$regex": pattern, "$options": opts})
+}
+
+// RegexLiteral is like Regex but escapes text so that it is matched literally
+func (q *QueryBuilder[T]) RegexLiteral(field, text string, opts string) *QueryBuilder[T] {
+	return q.Regex(field, regexp.QuoteMeta(text), opts)
+}
+
+// ElemMatch adds a {field: {$elemMatch: conditions}} condition to the filter, matching documents
+// whose array field has a single element meeting every condition
+func (q *QueryBuilder[T]) ElemMatch(field string, conditions bson.M) *QueryBuilder[T] {
+	return q.setCondition(field, "$elemMatch", conditions)
+}
+
+// In adds a {field: {$in: values}} condition to the filter
+func (q *QueryBuilder[T]) In(field string, values ...interface{}) *QueryBuilder[T] {
+	return q.setCondition(field, "$in", bson.A(values))
+}
+
+// NotIn adds a {field: {$nin: values}} condition to the filter
+func (q *QueryBuilder[T]) NotIn(field string, values ...interface{}) *QueryBuilder[T] {
+	return q.setCondition(field, "$nin", bson.A(values))
+}
+
+// InSlice is like In but takes a typed slice such as []int or []primitive.ObjectID, which passed
+// to In would be matched as a single array value. It panics if values isn't a slice.
+func (q *QueryBuilder[T]) InSlice(field string, values interface{}) *QueryBuilder[T] {
+	return q.setCondition(field, "$in", sliceValues(values))
+}
+
+// NotInSlice is like NotIn but takes a typed slice, see InSlice
+func (q *QueryBuilder[T]) NotInSlice(field string, values interface{}) *QueryBuilder[T] {
+	return q.setCondition(field, "$nin", sliceValues(values))
+}
+
+func (q *QueryBuilder[T]) setCondition(field, operator string, value interface{}) *QueryBuilder[T] {
+	return q.setField(field, bson.M{operator: value})
+}
+
+func sliceValues(values interface{}) bson.A {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic(fmt.Sprintf("expected a slice of values, got %T", values))
+	}
+	result := make(bson.A, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result
+}
+
+// AddFields computes extra fields from expressions such as {"$concat": ["$first", " ", "$last"]},
+// running QueryMany, QueryManyInto & QueryChan as an aggregation. The computed fields can be
+// decoded into another type through QueryManyInto.
+func (q *QueryBuilder[T]) AddFields(fields bson.M) *QueryBuilder[T] {
+	if q.addFields == nil {
+		q.addFields = bson.M{}
+	}
+	for key, value := range fields {
+		q.addFields[key] = value
+	}
 	return q
 }
 
@@ -45,18 +176,65 @@ func (q *QueryBuilder[T]) ProjectionB(projection bson.M) *QueryBuilder[T] {
 	return q
 }
 
+func (q *QueryBuilder[T]) ProjectFields(fields ...string) *QueryBuilder[T] {
+	return q.projectFields(fields, 1)
+}
+
+func (q *QueryBuilder[T]) ExcludeFields(fields ...string) *QueryBuilder[T] {
+	return q.projectFields(fields, 0)
+}
+
+// ProjectSlice limits the array field to its first n elements, or its last n when n is negative
+func (q *QueryBuilder[T]) ProjectSlice(field string, n int) *QueryBuilder[T] {
+	return q.projectOperator(field, bson.M{"$slice": n})
+}
+
+// ProjectElemMatch limits the array field to its first element matching match
+func (q *QueryBuilder[T]) ProjectElemMatch(field string, match bson.M) *QueryBuilder[T] {
+	return q.projectOperator(field, bson.M{"$elemMatch": match})
+}
+
+func (q *QueryBuilder[T]) projectOperator(field string, operator bson.M) *QueryBuilder[T] {
+	if q.projection == nil {
+		q.projection = bson.M{}
+	}
+	q.projection[field] = operator
+	return q
+}
+
+func (q *QueryBuilder[T]) projectFields(fields []string, value int) *QueryBuilder[T] {
+	if q.projection == nil {
+		q.projection = bson.M{}
+	}
+	for _, field := range fields {
+		q.projection[field] = value
+	}
+	if err := validateProjection(q.projection); err != nil {
+		panic(err)
+	}
+	return q
+}
+
 func (q *QueryBuilder[T]) Sort(sort string) *QueryBuilder[T] {
-	var sortMap []map[string]int
-	err := json.Unmarshal([]byte(sort), &sortMap)
+	parsed, err := parseSort(sort)
 	if err != nil {
 		panic(err)
 	}
+	q.sort = parsed
+	return q
+}
 
-	q.sort = bson.D{}
-	for _, m := range sortMap {
-		for k, v := range m {
-			q.sort = append(q.sort, bson.E{Key: k, Value: v})
-		}
+func (q *QueryBuilder[T]) SortAsc(fields ...string) *QueryBuilder[T] {
+	return q.appendSort(fields, 1)
+}
+
+func (q *QueryBuilder[T]) SortDesc(fields ...string) *QueryBuilder[T] {
+	return q.appendSort(fields, -1)
+}
+
+func (q *QueryBuilder[T]) appendSort(fields []string, order int) *QueryBuilder[T] {
+	for _, field := range fields {
+		q.sort = append(q.sort, bson.E{Key: field, Value: order})
 	}
 	return q
 }
@@ -71,25 +249,450 @@ func (q *QueryBuilder[T]) Pageable(pageable [2]int) *QueryBuilder[T] {
 	return q
 }
 
+func (q *QueryBuilder[T]) BatchSize(batchSize int) *QueryBuilder[T] {
+	q.batchSize = batchSize
+	return q
+}
+
+// Hint forces the query to use an index, given either by name or by its key spec as a bson.D
+func (q *QueryBuilder[T]) Hint(index interface{}) *QueryBuilder[T] {
+	switch index.(type) {
+	case string, bson.D:
+		q.hint = index
+	default:
+		panic(fmt.Sprintf("invalid hint of type %T: expected an index name or a bson.D key spec", index))
+	}
+	return q
+}
+
+// MaxTime makes the server abort the query once it has run for d, failing with a
+// MaxTimeMSExpired error independently of the context's deadline
+func (q *QueryBuilder[T]) MaxTime(d time.Duration) *QueryBuilder[T] {
+	q.maxTime = d
+	return q
+}
+
+func (q *QueryBuilder[T]) ReadPreference(rp *readpref.ReadPref) *QueryBuilder[T] {
+	q.readPreference = rp
+	return q
+}
+
+// MaxStaleness only reads from secondaries lagging at most d behind the primary, reading from
+// secondaries preferably unless ReadPreference sets another mode. The server requires d to be
+// at least 90 seconds, shorter durations failing the query.
+func (q *QueryBuilder[T]) MaxStaleness(d time.Duration) *QueryBuilder[T] {
+	q.maxStaleness = d
+	return q
+}
+
+func (q *QueryBuilder[T]) ReadConcern(rc *readconcern.ReadConcern) *QueryBuilder[T] {
+	q.readConcern = rc
+	return q
+}
+
+// Database runs the query against the collection of the same name in another database of the client
+func (q *QueryBuilder[T]) Database(name string) *QueryBuilder[T] {
+	q.databaseName = name
+	return q
+}
+
+// Collection runs the query against a sibling collection instead of the repository's collection
+func (q *QueryBuilder[T]) Collection(name string) *QueryBuilder[T] {
+	q.collectionName = name
+	return q
+}
+
+// StrictFields makes the query fail when its filter references top level fields that are not
+// part of the model, instead of silently matching nothing. Operators such as $or are allowed.
+func (q *QueryBuilder[T]) StrictFields() *QueryBuilder[T] {
+	q.strictFields = true
+	return q
+}
+
+// DryRun makes Delete & Unset count the documents they would change instead of changing
+// them, returning that count along with ErrDryRun. They are the only writes run from a query:
+// repository writes such as UpdateByIdAndReturn, Backfill & RenameField take no query & always write.
+func (q *QueryBuilder[T]) DryRun() *QueryBuilder[T] {
+	q.dryRun = true
+	return q
+}
+
 func (q *QueryBuilder[T]) Context(ctx context.Context) *QueryBuilder[T] {
 	q.context = ctx
 	return q
 }
 
-func (q *QueryBuilder[T]) Count() (int64, error) {
-	return q.repo.Count(q)
+// withContext returns a copy of the query running with the context passed to a terminal, if any,
+// which takes precedence over the context set with Context
+func (q *QueryBuilder[T]) withContext(ctx []context.Context) *QueryBuilder[T] {
+	if len(ctx) == 0 {
+		return q
+	}
+	withContext := *q
+	withContext.context = ctx[0]
+	return &withContext
 }
 
-func (q *QueryBuilder[T]) QueryOne() (T, error) {
-	return q.repo.QueryOne(q)
+func (q *QueryBuilder[T]) Count(ctx ...context.Context) (int64, error) {
+	q = q.withContext(ctx)
+	if err := q.checkFields(); err != nil {
+		return 0, err
+	}
+	return q.repo.Count(q.context, q)
+}
+
+// CountEstimate returns a fast estimate from the collection metadata when the query has no filter,
+// which may be off after unclean shutdowns or during orphaned chunk migrations on sharded
+// clusters, and the exact count of Count otherwise
+func (q *QueryBuilder[T]) CountEstimate(ctx ...context.Context) (int64, error) {
+	q = q.withContext(ctx)
+	if err := q.checkFields(); err != nil {
+		return 0, err
+	}
+	// the estimate is only available for the repository's own collection
+	if len(q.filter) == 0 && len(q.orderedFilter) == 0 && q.databaseName == "" && q.collectionName == "" {
+		return q.repo.EstimatedCount(q.context)
+	}
+	return q.repo.Count(q.context, q)
 }
 
-func (q *QueryBuilder[T]) QueryMany() ([]T, error) {
-	return q.repo.QueryMany(q)
+func (q *QueryBuilder[T]) QueryOne(ctx ...context.Context) (T, error) {
+	q = q.withContext(ctx)
+	if err := q.checkFields(); err != nil {
+		var result T
+		return result, err
+	}
+	return q.repo.QueryOne(q.context, q)
 }
 
-func (q *QueryBuilder[T]) Delete() (int64, error) {
-	return q.repo.Delete(q)
+// First returns the first matching item in the order of the query's sort, or ErrNotFound when
+// nothing matches
+func (q *QueryBuilder[T]) First(ctx ...context.Context) (T, error) {
+	q = q.withContext(ctx)
+	var result T
+	// a page size of one keeps the offset of the original page
+	one := *q
+	one.pageable = [2]int{q.pageable[0] * q.pageable[1], 1}
+	results, err := one.QueryMany()
+	if err != nil {
+		return result, err
+	}
+	if len(results) == 0 {
+		return result, ErrNotFound
+	}
+	return results[0], nil
+}
+
+func (q *QueryBuilder[T]) QueryMany(ctx ...context.Context) ([]T, error) {
+	q = q.withContext(ctx)
+	if err := q.checkFields(); err != nil {
+		return nil, err
+	}
+	return q.repo.QueryMany(q.context, q)
+}
+
+// QueryManyPtr is like QueryMany but returns pointers to the items, which can be modified in place
+// when chaining post processing over the results
+func (q *QueryBuilder[T]) QueryManyPtr(ctx ...context.Context) ([]*T, error) {
+	q = q.withContext(ctx)
+	results, err := q.QueryMany()
+	if err != nil {
+		return nil, err
+	}
+	pointers := make([]*T, len(results))
+	for i := range results {
+		pointers[i] = &results[i]
+	}
+	return pointers, nil
+}
+
+// QueryIds returns only the ObjectID ids of the matching documents, projecting away every other field
+func (q *QueryBuilder[T]) QueryIds(ctx ...context.Context) ([]primitive.ObjectID, error) {
+	q = q.withContext(ctx)
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	idFieldIndex, err := q.idFieldIndex(t)
+	if err != nil {
+		return nil, err
+	}
+	if idType := t.Field(idFieldIndex).Type; idType != objectIdType {
+		return nil, fmt.Errorf("QueryIds requires a primitive.ObjectID id, got %s", idType)
+	}
+
+	projection := q.projection
+	q.projection = bson.M{"_id": 1}
+	defer func() { q.projection = projection }()
+
+	results, err := q.QueryMany()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(results))
+	for i := range results {
+		ids[i] = getId(&results[i], idFieldIndex).(primitive.ObjectID)
+	}
+	return ids, nil
+}
+
+// idFieldIndex returns the id field index the repository found when it was created, looking it
+// up again only for other implementations of Repository
+func (q *QueryBuilder[T]) idFieldIndex(t reflect.Type) (int, error) {
+	switch repo := q.repo.(type) {
+	case *MongoRepository[T]:
+		return repo.idFieldIndex, nil
+	case *InMemoryRepository[T]:
+		return repo.idFieldIndex, nil
+	}
+	return findIdFieldIndex(t)
+}
+
+func (q *QueryBuilder[T]) QueryChan(ctx context.Context) (<-chan T, <-chan error) {
+	if err := q.checkFields(); err != nil {
+		results := make(chan T)
+		errs := make(chan error, 1)
+		errs <- err
+		close(results)
+		close(errs)
+		return results, errs
+	}
+	return q.repo.QueryChan(ctx, q)
+}
+
+func (q *QueryBuilder[T]) Explain(ctx context.Context) (bson.M, error) {
+	if err := q.checkFields(); err != nil {
+		return nil, err
+	}
+	return q.repo.Explain(ctx, q)
+}
+
+func (q *QueryBuilder[T]) Delete(ctx ...context.Context) (int64, error) {
+	q = q.withContext(ctx)
+	if err := q.checkFields(); err != nil {
+		return 0, err
+	}
+	if q.dryRun {
+		count, err := q.repo.Count(q.context, q)
+		if err != nil {
+			return 0, err
+		}
+		return count, ErrDryRun
+	}
+	return q.repo.Delete(q.context, q)
+}
+
+// QueryManyInto runs the query like QueryMany but decodes the results into R, which suits
+// projections that do not fit the repository's type.
+func QueryManyInto[R any, T any](q *QueryBuilder[T]) ([]R, error) {
+	if err := q.checkFields(); err != nil {
+		return nil, err
+	}
+	var results []R
+	if repo, ok := q.repo.(*MongoRepository[T]); ok {
+		_, err := repo.find(q.context, "QueryManyInto", q, &results)
+		return results, err
+	}
+
+	items, err := q.repo.QueryMany(q.context, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		data, err := bson.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var result R
+		if err := bson.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// QueryManyRaw returns the matching documents undecoded, keeping only the fields that are
+// present, for projections whose shape is only known at runtime
+func (q *QueryBuilder[T]) QueryManyRaw(ctx ...context.Context) ([]bson.M, error) {
+	q = q.withContext(ctx)
+	return QueryManyInto[bson.M](q)
+}
+
+// QueryOneRaw is like QueryOne but returns the document undecoded, or ErrNotFound when nothing
+// matches
+func (q *QueryBuilder[T]) QueryOneRaw(ctx ...context.Context) (bson.M, error) {
+	q = q.withContext(ctx)
+	// a page size of one keeps the offset of the original page
+	one := *q
+	one.pageable = [2]int{q.pageable[0] * q.pageable[1], 1}
+	results, err := QueryManyInto[bson.M](&one)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return results[0], nil
+}
+
+// Unset removes fields from every matching document, returning the number of documents modified
+func (q *QueryBuilder[T]) Unset(fields ...string) (int64, error) {
+	if len(fields) == 0 {
+		return 0, errors.New("unset requires at least one field")
+	}
+	if err := q.checkFields(); err != nil {
+		return 0, err
+	}
+	if q.dryRun {
+		count, err := q.repo.Count(q.context, q)
+		if err != nil {
+			return 0, err
+		}
+		return count, ErrDryRun
+	}
+	return q.repo.Unset(q.context, q, fields...)
+}
+
+func (q *QueryBuilder[T]) checkFields() error {
+	if !q.strictFields {
+		return nil
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	known := map[string]bool{"_id": true}
+	collectFieldNames(t, known, map[reflect.Type]bool{})
+
+	var unknown []string
+	for key := range q.filterMap() {
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+		if !known[strings.SplitN(key, ".", 2)[0]] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("filter references fields not in %s: %s", t.Name(), strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// collectFieldNames gathers the top level bson field names of t, including those of inlined structs
+func collectFieldNames(t reflect.Type, names map[string]bool, visited map[reflect.Type]bool) {
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isIgnored(field) {
+			continue
+		}
+		if nested, ok := nestedStructType(field.Type); ok && isInline(field) {
+			if !visited[nested] {
+				collectFieldNames(nested, names, visited)
+			}
+			continue
+		}
+		names[getFieldName(field)] = true
+	}
+}
+
+// parseSort reads either an array of single key objects like [{"age":-1},{"name":1}] or a
+// single object like {"age":-1,"name":1}, keeping the keys in the order they are declared.
+func parseSort(sort string) (bson.D, error) {
+	decoder := json.NewDecoder(strings.NewReader(sort))
+
+	parsed := bson.D{}
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('['):
+		for decoder.More() {
+			if err := expectDelim(decoder, '{'); err != nil {
+				return nil, err
+			}
+			if parsed, err = parseSortObject(decoder, parsed); err != nil {
+				return nil, err
+			}
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, err
+		}
+	case json.Delim('{'):
+		if parsed, err = parseSortObject(decoder, parsed); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid sort %s: expected an object or array", sort)
+	}
+	return parsed, nil
+}
+
+func parseSortObject(decoder *json.Decoder, parsed bson.D) (bson.D, error) {
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var order int
+		if err := decoder.Decode(&order); err != nil {
+			return nil, fmt.Errorf("invalid sort order for %v: %v", key, err)
+		}
+		parsed = append(parsed, bson.E{Key: key.(string), Value: order})
+	}
+	return parsed, expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("invalid sort: expected %v but found %v", delim, token)
+	}
+	return nil
+}
+
+// validateProjection rejects projections mixing inclusion and exclusion,
+// which mongo only allows for the _id field.
+func validateProjection(projection bson.M) error {
+	var included, excluded []string
+	for field, value := range projection {
+		if field == "_id" {
+			continue
+		}
+		include, ok := projectionFlag(value)
+		if !ok {
+			continue
+		}
+		if include {
+			included = append(included, field)
+		} else {
+			excluded = append(excluded, field)
+		}
+	}
+	if len(included) > 0 && len(excluded) > 0 {
+		return fmt.Errorf("projection cannot mix inclusion %v and exclusion %v", included, excluded)
+	}
+	return nil
+}
+
+func projectionFlag(value interface{}) (include bool, ok bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case int:
+		return v != 0, true
+	case int32:
+		return v != 0, true
+	case int64:
+		return v != 0, true
+	case float64:
+		return v != 0, true
+	}
+	return false, false
 }
 
 func replaceParams(query string, params ...interface{}) string {
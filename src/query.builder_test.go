@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSortPreservesOrder(t *testing.T) {
+	expected := bson.D{{Key: "b", Value: 1}, {Key: "a", Value: -1}, {Key: "c", Value: 1}}
+
+	for i := 0; i < 20; i++ {
+		query := &QueryBuilder[TestModel]{}
+		query.Sort(`{"b":1,"a":-1,"c":1}`)
+		if !reflect.DeepEqual(query.sort, expected) {
+			t.Fatalf("Expected sort %v, got %v", expected, query.sort)
+		}
+
+		query.Sort(`[{"b":1},{"a":-1},{"c":1}]`)
+		if !reflect.DeepEqual(query.sort, expected) {
+			t.Fatalf("Expected sort %v, got %v", expected, query.sort)
+		}
+	}
+}
+
+func TestSortInvalid(t *testing.T) {
+	invalidSorts := []string{`{"age":"asc"}`, `[{"age":1}`, `"age"`}
+	for _, sort := range invalidSorts {
+		if _, err := parseSort(sort); err == nil {
+			t.Fatalf("Expected sort %s to be rejected", sort)
+		}
+	}
+}
+
+func TestSortAscDescAccumulate(t *testing.T) {
+	query := &QueryBuilder[TestModel]{}
+	query.SortDesc("age").SortAsc("name", "created_at")
+
+	expected := bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}, {Key: "created_at", Value: 1}}
+	if !reflect.DeepEqual(query.sort, expected) {
+		t.Fatalf("Expected sort %v, got %v", expected, query.sort)
+	}
+}
+
+func TestStrictFieldsUnknownField(t *testing.T) {
+	repo, err := NewInMemoryRepository[TestModel]()
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	_, err = repo.QueryRunner().
+		StrictFields().
+		Filter(`{"naem": ?1, "$or": [{"age": 30}, {"age": 40}]}`, "John").
+		QueryMany()
+	if err == nil || !strings.Contains(err.Error(), "naem") {
+		t.Fatalf("Expected error listing unknown field 'naem', got %v", err)
+	}
+
+	_, err = repo.QueryRunner().
+		StrictFields().
+		Filter(`{"name": ?1, "age": ?2}`, "John", 30).
+		Count()
+	if err != nil {
+		t.Fatalf("Expected known fields to be accepted, got %v", err)
+	}
+}
+
+func TestRegexLiteralEscapes(t *testing.T) {
+	query := &QueryBuilder[TestModel]{}
+	query.RegexLiteral("name", "a.b*(c)", "")
+
+	expected := bson.M{"name": bson.M{"$regex": `a\.b\*\(c\)`, "$options": ""}}
+	if !reflect.DeepEqual(query.filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, query.filter)
+	}
+}
+
+func TestProjectArrayOperators(t *testing.T) {
+	query := &QueryBuilder[TestModel]{}
+	query.ProjectFields("name").
+		ProjectSlice("tags", 2).
+		ProjectElemMatch("scores", bson.M{"value": bson.M{"$gte": 10}})
+
+	expected := bson.M{
+		"name":   1,
+		"tags":   bson.M{"$slice": 2},
+		"scores": bson.M{"$elemMatch": bson.M{"value": bson.M{"$gte": 10}}},
+	}
+	if !reflect.DeepEqual(query.projection, expected) {
+		t.Fatalf("Expected projection %v, got %v", expected, query.projection)
+	}
+}
+
+func TestFilterExpr(t *testing.T) {
+	query := &QueryBuilder[TestModel]{}
+	query.Filter(`{"$expr":{"$gt":["$spent", ?1]}}`, "$budget")
+
+	expected := bson.M{"$expr": bson.M{"$gt": bson.A{"$spent", "$budget"}}}
+	if !reflect.DeepEqual(query.filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, query.filter)
+	}
+}
+
+func TestInNotIn(t *testing.T) {
+	query := &QueryBuilder[TestModel]{}
+	query.NotIn("status", "archived", "deleted")
+
+	expected := bson.M{"status": bson.M{"$nin": bson.A{"archived", "deleted"}}}
+	if !reflect.DeepEqual(query.filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, query.filter)
+	}
+
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}
+	query = &QueryBuilder[TestModel]{}
+	query.InSlice("_id", ids)
+
+	expected = bson.M{"_id": bson.M{"$in": bson.A{ids[0], ids[1]}}}
+	if !reflect.DeepEqual(query.filter, expected) {
+		t.Fatalf("Expected filter %v, got %v", expected, query.filter)
+	}
+}
+
+func TestFilterD(t *testing.T) {
+	query := &QueryBuilder[TestModel]{}
+	query.FilterB(bson.M{"name": "ignored"}).
+		FilterD(bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 20}, {Key: "$lt", Value: 40}}}}).
+		In("name", "a", "b")
+
+	expected := bson.D{
+		{Key: "age", Value: bson.D{{Key: "$gte", Value: 20}, {Key: "$lt", Value: 40}}},
+		{Key: "name", Value: bson.M{"$in": bson.A{"a", "b"}}},
+	}
+	if !reflect.DeepEqual(query.orderedFilter, expected) || query.filter != nil {
+		t.Fatalf("Expected ordered filter %v, got %v & %v", expected, query.orderedFilter, query.filter)
+	}
+
+	repo := &MongoRepository[TestModel]{scope: bson.M{"tenant": "a"}}
+	scoped := bson.M{"$and": bson.A{bson.M{"tenant": "a"}, expected}}
+	if filter := repo.queryFilter(query); !reflect.DeepEqual(filter, scoped) {
+		t.Fatalf("Expected scoped filter %v, got %v", scoped, filter)
+	}
+
+	base := make(bson.D, 1, 2)
+	base[0] = bson.E{Key: "age", Value: 30}
+	(&QueryBuilder[TestModel]{}).FilterD(base).In("name", "a")
+	if extra := base[:2]; extra[1].Key != "" {
+		t.Fatalf("Expected FilterD not to append into the caller's filter, got %v", extra)
+	}
+
+	query.FilterB(bson.M{"name": "a"})
+	if query.orderedFilter != nil {
+		t.Fatalf("Expected FilterB to replace the ordered filter, got %v", query.orderedFilter)
+	}
+}
+
+func TestInSliceRejectsNonSlice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected InSlice with a non slice value to panic")
+		}
+	}()
+
+	query := &QueryBuilder[TestModel]{}
+	query.InSlice("age", 30)
+}
@@ -7,36 +7,120 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.opentelemetry.io/otel/trace"
 )
 
+type Repository[T any] interface {
+	QueryRunner() *QueryBuilder[T]
+	FindAll(ctx context.Context) ([]T, error)
+	FindById(ctx context.Context, id interface{}) (T, error)
+	FindByIds(ctx context.Context, ids []primitive.ObjectID) ([]T, error)
+	ExistsById(ctx context.Context, id interface{}) (bool, error)
+	CountAll(ctx context.Context) (int64, error)
+	EstimatedCount(ctx context.Context) (int64, error)
+	Count(ctx context.Context, query *QueryBuilder[T]) (int64, error)
+	Save(ctx context.Context, item T) (T, error)
+	SaveAll(ctx context.Context, items []T) ([]T, error)
+	InsertMany(ctx context.Context, items []T, ordered bool) ([]T, error)
+	DeleteById(ctx context.Context, id interface{}) error
+	DeleteAll(ctx context.Context) (int64, error)
+	Delete(ctx context.Context, query *QueryBuilder[T]) (int64, error)
+	Unset(ctx context.Context, query *QueryBuilder[T], fields ...string) (int64, error)
+	QueryOne(ctx context.Context, query *QueryBuilder[T]) (T, error)
+	QueryMany(ctx context.Context, query *QueryBuilder[T]) ([]T, error)
+	QueryChan(ctx context.Context, query *QueryBuilder[T]) (<-chan T, <-chan error)
+	Explain(ctx context.Context, query *QueryBuilder[T]) (bson.M, error)
+	AggregateOne(ctx context.Context, pipeline []bson.M) (bson.M, error)
+	AggregateMultiple(ctx context.Context, pipeline []bson.M) ([]bson.M, error)
+	AggregateTyped(ctx context.Context, pipeline []bson.M) ([]T, error)
+}
+
+var _ Repository[any] = (*MongoRepository[any])(nil)
+
 type MongoRepository[T any] struct {
-	collection   *mongo.Collection
-	idFieldIndex int
+	collection     *mongo.Collection
+	idFieldIndex   int
+	readPreference *readpref.ReadPref
+	readConcern    *readconcern.ReadConcern
+	writeConcern   *writeconcern.WriteConcern
+	retryAttempts  int
+	retryBackoff   time.Duration
+	tracer         trace.Tracer
+	logger         Logger
+
+	slowQueryThreshold time.Duration
+	onSlowQuery        SlowQueryFunc
+	defaultTimeout     time.Duration
+
+	countCache     *countCache
+	scope          bson.M
+	strictIndexes  bool
+	idChunkSize    int
+	defaultSort    bson.D
+	defaultContext context.Context
 }
 
-func NewMongoRepository[T any](collection *mongo.Collection) (*MongoRepository[T], error) {
+func NewMongoRepository[T any](collection *mongo.Collection, opts ...Option) (*MongoRepository[T], error) {
+	if err := checkModelType[T](); err != nil {
+		return nil, err
+	}
 
+	var o constructOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	repo := &MongoRepository[T]{
-		collection: collection,
+		collection:    collection,
+		countCache:    &countCache{},
+		logger:        o.logger,
+		strictIndexes: o.strictIndexes,
 	}
 
 	if err := repo.setIdField(); err != nil {
 		return nil, err
 	}
+	if o.skipIndexCreation {
+		return repo, nil
+	}
 	if err := repo.ensureSimpleIndexes(); err != nil {
 		return nil, err
 	}
 	if err := repo.ensureCompoundIndex(); err != nil {
 		return nil, err
 	}
+	if err := repo.ensureTagSchema(); err != nil {
+		return nil, err
+	}
 	return repo, nil
 }
 
+// NewMongoRepositoryWithOptions is like NewMongoRepository, configured by a RepoOptions struct
+// instead of a list of Option
+func NewMongoRepositoryWithOptions[T any](collection *mongo.Collection, opts RepoOptions) (*MongoRepository[T], error) {
+	return NewMongoRepository[T](collection, opts.options()...)
+}
+
+// checkModelType rejects pointer & non struct models, whose zero values can't be reflected on or saved
+func checkModelType[T any]() error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		return fmt.Errorf("model type %s must not be a pointer, instantiate the repository with %s instead", t, t.Elem())
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("model type %s must be a struct", t)
+	}
+	return nil
+}
+
 func (r *MongoRepository[T]) setIdField() error {
 	var dummy T
 	t := reflect.TypeOf(dummy)
@@ -44,19 +128,24 @@ func (r *MongoRepository[T]) setIdField() error {
 		t = t.Elem()
 	}
 
+	index, err := findIdFieldIndex(t)
+	if err != nil {
+		return err
+	}
+	r.idFieldIndex = index
+	return nil
+}
+
+// findIdFieldIndex returns the index of the field named _id by the first segment of its bson tag,
+// the others being options such as omitempty
+func findIdFieldIndex(t reflect.Type) (int, error) {
 	for i := 0; i < t.NumField(); i++ {
-		if tag := t.Field(i).Tag.Get("bson"); tag != "" {
-			tags := strings.Split(tag, ",")
-			for _, t := range tags {
-				if strings.TrimSpace(t) == "_id" {
-					r.idFieldIndex = i
-					return nil
-				}
-			}
+		if tag := t.Field(i).Tag.Get("bson"); strings.TrimSpace(strings.Split(tag, ",")[0]) == "_id" {
+			return i, nil
 		}
 	}
 
-	return errors.New("type does not have a field with bson:\"_id\" tag")
+	return 0, errors.New("type does not have a field with bson:\"_id\" tag")
 }
 
 func (r *MongoRepository[T]) ensureSimpleIndexes() error {
@@ -66,53 +155,187 @@ func (r *MongoRepository[T]) ensureSimpleIndexes() error {
 		t = t.Elem()
 	}
 
+	indexes, err := collectIndexes(t, "", map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if err := r.createIndex(index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndex keeps an existing index that conflicts with index, logging the conflict, unless
+// the repository was constructed with StrictIndexes
+func (r *MongoRepository[T]) createIndex(index mongo.IndexModel) error {
+	_, err := r.collection.Indexes().CreateOne(context.Background(), index)
+	if err != nil && !r.strictIndexes && isIndexConflict(err) {
+		if r.logger != nil {
+			r.logger.LogQuery("CreateIndex", index.Keys, 0, err)
+		}
+		return nil
+	}
+	return err
+}
+
+// collectIndexes walks the fields of t & of its nested structs, keying nested indexes by their dotted bson path
+func collectIndexes(t reflect.Type, prefix string, visited map[reflect.Type]bool) ([]mongo.IndexModel, error) {
+	visited[t] = true
+	defer delete(visited, t)
+
 	var indexes []mongo.IndexModel
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		fieldName := getFieldName(field)
+		if isIgnored(field) {
+			continue
+		}
+		fieldName := prefix + getFieldName(field)
 
 		if tag := field.Tag.Get("index"); tag != "" {
-			splitTags := strings.Split(tag, ",")
-			var indexType interface{}
-			indexOptions := options.IndexOptions{}
-			for _, splitTag := range splitTags {
-				splitTag = strings.TrimSpace(splitTag)
-				switch splitTag {
-				case "unique":
-					indexOptions.SetUnique(true)
-				case "1", "-1":
-					indexType, _ = strconv.Atoi(splitTag)
-				case "sparse":
-					indexOptions.SetSparse(true)
-				case "text", "2dsphere":
-					indexType = splitTag
-				default:
-					return errors.New("unsupported index tag: " + splitTag)
-				}
+			index, err := parseIndexTag(fieldName, tag)
+			if err != nil {
+				return nil, err
+			}
+			if isWildcardIndex(index) && !isDocumentType(field.Type) {
+				return nil, fmt.Errorf("wildcard index on field %s requires a struct or map type, got %s", fieldName, field.Type)
 			}
-			index := mongo.IndexModel{
-				Keys:    bson.D{{Key: fieldName, Value: indexType}},
-				Options: &indexOptions,
+			// sparse indexes skip missing fields only, so without omitempty every zero value
+			// is stored & indexed, conflicting with the next one
+			isUniqueSparse := index.Options.Unique != nil && index.Options.Sparse != nil
+			if isUniqueSparse && !hasBsonOption(field, "omitempty") {
+				return nil, fmt.Errorf("unique sparse index on field %s requires omitempty in its bson tag", fieldName)
 			}
 			indexes = append(indexes, index)
 		}
+
+		if nested, ok := nestedStructType(field.Type); ok && !visited[nested] {
+			nestedPrefix := fieldName + "."
+			if isInline(field) {
+				nestedPrefix = prefix
+			}
+			nestedIndexes, err := collectIndexes(nested, nestedPrefix, visited)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, nestedIndexes...)
+		}
 	}
-	if len(indexes) > 0 {
-		_, err := r.collection.Indexes().CreateMany(context.Background(), indexes)
-		return err
+	return indexes, nil
+}
+
+func parseIndexTag(fieldName string, tag string) (mongo.IndexModel, error) {
+	var indexType interface{}
+	indexOptions := options.IndexOptions{}
+	for _, splitTag := range strings.Split(tag, ",") {
+		splitTag = strings.TrimSpace(splitTag)
+		switch splitTag {
+		case "unique":
+			indexOptions.SetUnique(true)
+		case "sparse":
+			indexOptions.SetSparse(true)
+		case "1", "-1", "text", "2dsphere", "hashed", "wildcard", "$**":
+			if indexType != nil {
+				return mongo.IndexModel{}, fmt.Errorf("conflicting index tags on field %s: %v and %s", fieldName, indexType, splitTag)
+			}
+			if order, err := strconv.Atoi(splitTag); err == nil {
+				indexType = order
+			} else {
+				indexType = splitTag
+			}
+		default:
+			return mongo.IndexModel{}, fmt.Errorf("unsupported index tag on field %s: %s", fieldName, splitTag)
+		}
 	}
-	return nil
+	if indexType == "hashed" && indexOptions.Unique != nil {
+		return mongo.IndexModel{}, fmt.Errorf("hashed index on field %s cannot be unique", fieldName)
+	}
+	if indexType == "wildcard" || indexType == "$**" {
+		if indexOptions.Unique != nil {
+			return mongo.IndexModel{}, fmt.Errorf("wildcard index on field %s cannot be unique", fieldName)
+		}
+		// wildcard indexes cover every sub field of the document
+		fieldName, indexType = fieldName+".$**", 1
+	}
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: fieldName, Value: indexType}},
+		Options: &indexOptions,
+	}, nil
+}
+
+func isWildcardIndex(index mongo.IndexModel) bool {
+	keys, ok := index.Keys.(bson.D)
+	return ok && len(keys) == 1 && strings.HasSuffix(keys[0].Key, "$**")
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	decimal128Type     = reflect.TypeOf(primitive.Decimal128{})
+	rawValueType       = reflect.TypeOf(bson.RawValue{})
+	marshalerType      = reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	valueMarshalerType = reflect.TypeOf((*bson.ValueMarshaler)(nil)).Elem()
+	primitivePkgPath   = reflect.TypeOf(primitive.Timestamp{}).PkgPath()
+)
+
+// nestedStructType returns the struct stored as a sub document by a field of type t. Structs of
+// the primitive package such as Timestamp or Decimal128, and types encoding themselves, are
+// stored as single values.
+func nestedStructType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || t == rawValueType || t.PkgPath() == primitivePkgPath {
+		return nil, false
+	}
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(marshalerType) || ptr.Implements(valueMarshalerType) {
+		return nil, false
+	}
+	return t, true
+}
+
+// isDocumentType reports whether a field of type t is stored as a sub document
+func isDocumentType(t reflect.Type) bool {
+	if _, ok := nestedStructType(t); ok {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Map
+}
+
+func isInline(field reflect.StructField) bool {
+	return hasBsonOption(field, "inline")
 }
 
+func hasBsonOption(field reflect.StructField, name string) bool {
+	for _, option := range strings.Split(field.Tag.Get("bson"), ",")[1:] {
+		if strings.TrimSpace(option) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldName returns the key the bson encoder stores field under, its lowercased Go name
+// unless the tag names it
 func getFieldName(field reflect.StructField) string {
-	fieldName := field.Name
+	fieldName := strings.ToLower(field.Name)
 	if tag := field.Tag.Get("bson"); tag != "" {
-		splitTags := strings.Split(tag, ",")
-		fieldName = splitTags[0]
+		if splitTags := strings.Split(tag, ","); splitTags[0] != "" {
+			fieldName = splitTags[0]
+		}
 	}
 	return fieldName
 }
 
+// isIgnored reports whether the bson encoder skips field, being unexported or tagged bson:"-"
+func isIgnored(field reflect.StructField) bool {
+	return !field.IsExported() || field.Tag.Get("bson") == "-"
+}
+
 func (r *MongoRepository[T]) ensureCompoundIndex() error {
 	var t T
 	elemType := reflect.TypeOf(t)
@@ -122,208 +345,1477 @@ func (r *MongoRepository[T]) ensureCompoundIndex() error {
 		return nil // No index to create
 	}
 
-	cleanedCindex := strings.ReplaceAll(cindexTag, "{", "")
+	indexes, err := parseCompoundIndexTag(cindexTag)
+	if err != nil {
+		return err
+	}
+	for _, indexModel := range indexes {
+		if err := r.createIndex(indexModel); err != nil {
+			return fmt.Errorf("failed to create index: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// parseCompoundIndexTag parses a cindex tag of ; separated {field:order,...} indexes, orders being
+// 1, -1, text, 2dsphere or hashed. The unique:true, sparse:true & name:<index name> options may
+// be given along with the keys, name being a key when its value is an order.
+func parseCompoundIndexTag(tag string) ([]mongo.IndexModel, error) {
+	cleanedCindex := strings.ReplaceAll(tag, "{", "")
 	cleanedCindex = strings.ReplaceAll(cleanedCindex, "}", "")
-	indexes := strings.Split(cleanedCindex, ";")
 
-	for _, index := range indexes {
+	var indexes []mongo.IndexModel
+	for _, index := range strings.Split(cleanedCindex, ";") {
 		indexKeys := bson.D{}
-		parts := strings.Split(index, ",")
-
-		for _, part := range parts {
+		indexOptions := options.Index()
+		typeCounts := map[string]int{}
+		for _, part := range strings.Split(index, ",") {
 			kv := strings.Split(part, ":")
 			if len(kv) != 2 {
-				return fmt.Errorf("invalid compound index format: %s", part)
+				return nil, fmt.Errorf("invalid compound index format: %s", part)
 			}
 
-			fieldName := kv[0]
-			order, err := strconv.Atoi(kv[1])
-			if err != nil {
-				return fmt.Errorf("invalid compound index order: %s", kv[1])
+			fieldName, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			if fieldName == "unique" || fieldName == "sparse" {
+				enabled, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid compound index option %s: %s", fieldName, value)
+				}
+				if fieldName == "unique" {
+					indexOptions.SetUnique(enabled)
+				} else {
+					indexOptions.SetSparse(enabled)
+				}
+				continue
+			}
+			if _, err := strconv.Atoi(value); fieldName == "name" && err != nil && !isIndexType(value) {
+				indexOptions.SetName(value)
+				continue
 			}
 
+			if isIndexType(value) {
+				typeCounts[value]++
+				indexKeys = append(indexKeys, bson.E{Key: fieldName, Value: value})
+				continue
+			}
+			order, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid compound index order: %s", value)
+			}
 			indexKeys = append(indexKeys, bson.E{Key: fieldName, Value: order})
 		}
 
-		indexModel := mongo.IndexModel{
-			Keys: indexKeys,
+		if len(indexKeys) == 0 {
+			return nil, fmt.Errorf("compound index %s has no keys", index)
 		}
-
-		_, err := r.collection.Indexes().CreateOne(context.TODO(), indexModel)
-		if err != nil {
-			return fmt.Errorf("failed to create index: %v", err)
+		if typeCounts["text"] > 1 || typeCounts["hashed"] > 1 {
+			return nil, fmt.Errorf("compound index %s can only have one text & one hashed key", index)
+		}
+		if typeCounts["text"] > 0 && typeCounts["2dsphere"] > 0 {
+			return nil, fmt.Errorf("compound index %s cannot mix text & 2dsphere keys", index)
+		}
+		if typeCounts["hashed"] > 0 && indexOptions.Unique != nil && *indexOptions.Unique {
+			return nil, fmt.Errorf("compound index %s with a hashed key cannot be unique", index)
 		}
+		indexes = append(indexes, mongo.IndexModel{Keys: indexKeys, Options: indexOptions})
 	}
-
-	return nil
+	return indexes, nil
 }
 
-func (r *MongoRepository[T]) QueryRunner() *QueryBuilder[T] {
-	return &QueryBuilder[T]{context: context.TODO(), repo: r}
+func isIndexType(value string) bool {
+	return value == "text" || value == "2dsphere" || value == "hashed"
 }
 
-func (r *MongoRepository[T]) FindAll() ([]T, error) {
-	var results []T
-	cursor, err := r.collection.Find(context.TODO(), bson.M{})
+// EnsureIndex creates an index on keys at runtime, complementing the indexes declared with
+// struct tags, and returns its name. Creating an index that already exists is a no-op.
+func (r *MongoRepository[T]) EnsureIndex(ctx context.Context, keys bson.D, opts ...*options.IndexOptions) (string, error) {
+	indexModel := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.MergeIndexOptions(opts...),
+	}
+	var name string
+	err := r.execute(ctx, "EnsureIndex", keys, func(ctx context.Context) (err error) {
+		name, err = r.collection.Indexes().CreateOne(ctx, indexModel)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to create index: %w", err)
 	}
-	defer cursor.Close(context.TODO())
-	err = cursor.All(context.TODO(), &results)
-	return results, err
+	return name, nil
 }
 
-func (r *MongoRepository[T]) FindById(id primitive.ObjectID) (T, error) {
-	var result T
-	err := r.collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&result)
-	return result, err
+func (r *MongoRepository[T]) EnsureUniqueIndex(ctx context.Context, keys bson.D, opts ...*options.IndexOptions) (string, error) {
+	return r.EnsureIndex(ctx, keys, append(opts, options.Index().SetUnique(true))...)
 }
 
-func (r *MongoRepository[T]) FindByIds(ids []primitive.ObjectID) ([]T, error) {
-	var results []T
-	cursor, err := r.collection.Find(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
-	if err != nil {
-		return nil, err
+// Drop drops the collection along with its indexes. A scoped repository refuses to drop the
+// collection, as it holds documents outside of the scope.
+func (r *MongoRepository[T]) Drop(ctx context.Context) error {
+	if len(r.scope) > 0 {
+		return errors.New("cannot drop the collection of a scoped repository")
 	}
-	defer cursor.Close(context.TODO())
-	err = cursor.All(context.TODO(), &results)
-	return results, err
+	return r.execute(ctx, "Drop", nil, func(ctx context.Context) error {
+		return r.collection.Drop(ctx)
+	})
 }
 
-func (r *MongoRepository[T]) ExistsById(id primitive.ObjectID) (bool, error) {
-	count, err := r.collection.CountDocuments(context.TODO(), bson.M{"_id": id}, options.Count().SetLimit(1))
-	if err != nil {
-		return false, err
+// DropAndRecreateIndexes drops the collection, then creates the indexes & schema validator declared
+// by the struct tags of T again, leaving an empty but indexed collection.
+func (r *MongoRepository[T]) DropAndRecreateIndexes(ctx context.Context) error {
+	if err := r.Drop(ctx); err != nil {
+		return err
 	}
-	return count > 0, nil
+	if err := r.ensureSimpleIndexes(); err != nil {
+		return err
+	}
+	if err := r.ensureCompoundIndex(); err != nil {
+		return err
+	}
+	return r.ensureTagSchema()
 }
 
-func (r *MongoRepository[T]) CountAll() (int64, error) {
-	count, err := r.collection.CountDocuments(context.TODO(), bson.M{})
-	if err != nil {
-		return 0, err
+type IndexInfo struct {
+	Name   string `bson:"name"`
+	Keys   bson.D `bson:"key"`
+	Unique bool   `bson:"unique"`
+	Sparse bool   `bson:"sparse"`
+	// ExpireAfterSeconds is only set for TTL indexes
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+}
+
+// ListIndexes returns the indexes currently existing on the collection, including the _id index
+func (r *MongoRepository[T]) ListIndexes(ctx context.Context) ([]IndexInfo, error) {
+	var indexes []IndexInfo
+	err := r.execute(ctx, "ListIndexes", nil, func(ctx context.Context) error {
+		cursor, err := r.collection.Indexes().List(ctx)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &indexes)
+	})
+	return indexes, err
+}
+
+func (r *MongoRepository[T]) clone() *MongoRepository[T] {
+	clone := *r
+	return &clone
+}
+
+// Collection returns the underlying driver collection. Operations run on it directly skip
+// hooks, validation, retries, tracing & logging.
+func (r *MongoRepository[T]) Collection() *mongo.Collection {
+	return r.collection
+}
+
+func (r *MongoRepository[T]) Database() *mongo.Database {
+	return r.collection.Database()
+}
+
+// ForCollection returns a copy of the repository bound to another collection, keeping its
+// configuration. Indexes are not created on the new collection.
+func (r *MongoRepository[T]) ForCollection(collection *mongo.Collection) *MongoRepository[T] {
+	clone := r.clone()
+	clone.collection = collection
+	return clone
+}
+
+func (r *MongoRepository[T]) WithReadPreference(rp *readpref.ReadPref) *MongoRepository[T] {
+	clone := r.clone()
+	clone.readPreference = rp
+	return clone
+}
+
+func (r *MongoRepository[T]) WithReadConcern(rc *readconcern.ReadConcern) *MongoRepository[T] {
+	clone := r.clone()
+	clone.readConcern = rc
+	return clone
+}
+
+// readCollection applies the read preference & concern of the query, falling back to the repository defaults
+func (r *MongoRepository[T]) readCollection(query *QueryBuilder[T]) (*mongo.Collection, error) {
+	rp, rc := r.readPreference, r.readConcern
+	if query != nil && query.readPreference != nil {
+		rp = query.readPreference
 	}
-	return count, nil
+	if query != nil && query.readConcern != nil {
+		rc = query.readConcern
+	}
+	if query != nil && query.maxStaleness != 0 {
+		var err error
+		if rp, err = withMaxStaleness(rp, query.maxStaleness); err != nil {
+			return nil, err
+		}
+	}
+	collection := r.queryCollection(query)
+	if rp == nil && rc == nil {
+		return collection, nil
+	}
+
+	collectionOptions := options.Collection()
+	if rp != nil {
+		collectionOptions.SetReadPreference(rp)
+	}
+	if rc != nil {
+		collectionOptions.SetReadConcern(rc)
+	}
+	return collection.Clone(collectionOptions)
 }
 
-func (r *MongoRepository[T]) Count(query *QueryBuilder[T]) (int64, error) {
-	count, err := r.collection.CountDocuments(query.context, query.filter)
-	if err != nil {
-		return 0, err
+// queryCollection resolves the database & collection overrides of the query from the repository's client
+func (r *MongoRepository[T]) queryCollection(query *QueryBuilder[T]) *mongo.Collection {
+	if query == nil || (query.databaseName == "" && query.collectionName == "") {
+		return r.collection
 	}
-	return count, nil
+	database := r.collection.Database()
+	if query.databaseName != "" {
+		database = database.Client().Database(query.databaseName)
+	}
+	name := r.collection.Name()
+	if query.collectionName != "" {
+		name = query.collectionName
+	}
+	return database.Collection(name)
 }
 
-func (r *MongoRepository[T]) Save(item T) (T, error) {
-	v := reflect.ValueOf(&item).Elem()
-	idField := v.Field(r.idFieldIndex)
-	id := idField.Interface().(primitive.ObjectID)
-	if id.IsZero() {
-		id = primitive.NewObjectID()
-		idField.Set(reflect.ValueOf(id))
+// WithWriteConcern returns a copy of the repository whose Save, SaveAll & InsertMany use the given write concern.
+// writeconcern.Majority() survives failovers at the cost of latency, while writeconcern.Unacknowledged()
+// returns before the server has applied the write, so failures such as duplicate keys go unnoticed.
+func (r *MongoRepository[T]) WithWriteConcern(wc *writeconcern.WriteConcern) *MongoRepository[T] {
+	clone := r.clone()
+	clone.writeConcern = wc
+	return clone
+}
+
+const minMaxStaleness = 90 * time.Second
+
+// withMaxStaleness copies rp with a staleness bound, defaulting to a secondary preferred read
+func withMaxStaleness(rp *readpref.ReadPref, maxStaleness time.Duration) (*readpref.ReadPref, error) {
+	if maxStaleness < minMaxStaleness {
+		return nil, fmt.Errorf("max staleness %s is below the minimum of %s", maxStaleness, minMaxStaleness)
 	}
+	if rp == nil {
+		return readpref.New(readpref.SecondaryPreferredMode, readpref.WithMaxStaleness(maxStaleness))
+	}
+	if rp.Mode() == readpref.PrimaryMode {
+		return nil, errors.New("max staleness cannot be used with a primary read preference")
+	}
+	return readpref.New(rp.Mode(), readpref.WithMaxStaleness(maxStaleness), readpref.WithTagSets(rp.TagSets()...))
+}
 
-	_, err := r.collection.ReplaceOne(context.TODO(), bson.M{"_id": id}, item, options.Replace().SetUpsert(true))
-	if err != nil {
-		return item, err
+func (r *MongoRepository[T]) writeCollection(query *QueryBuilder[T]) (*mongo.Collection, error) {
+	collection := r.queryCollection(query)
+	if r.writeConcern == nil {
+		return collection, nil
 	}
-	return item, nil
+	return collection.Clone(options.Collection().SetWriteConcern(r.writeConcern))
 }
 
-func (r *MongoRepository[T]) SaveAll(items []T) ([]T, error) {
-	var writes []mongo.WriteModel
-	for i := range items {
-		v := reflect.ValueOf(&items[i]).Elem()
-		idField := v.Field(r.idFieldIndex)
-		id := idField.Interface().(primitive.ObjectID)
-		if id.IsZero() {
-			id = primitive.NewObjectID()
-			idField.Set(reflect.ValueOf(id))
-		}
+// WithContext returns a copy of the repository whose queries run with ctx by default. A context
+// passed to a query terminal takes precedence over one set with Context, which takes precedence
+// over this default, itself falling back to context.TODO().
+func (r *MongoRepository[T]) WithContext(ctx context.Context) *MongoRepository[T] {
+	clone := r.clone()
+	clone.defaultContext = ctx
+	return clone
+}
 
-		write := mongo.NewReplaceOneModel().
-			SetFilter(bson.M{"_id": id}).
-			SetReplacement(items[i]).
-			SetUpsert(true)
-		writes = append(writes, write)
+func (r *MongoRepository[T]) QueryRunner() *QueryBuilder[T] {
+	ctx := r.defaultContext
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	return &QueryBuilder[T]{context: ctx, repo: r}
+}
+
+func (r *MongoRepository[T]) FindAll(ctx context.Context) ([]T, error) {
+	return r.findAll(ctx, "FindAll")
+}
+
+// FindAllWith fetches every document like FindAll, applying find options such as sort, limit & projection
+func (r *MongoRepository[T]) FindAllWith(ctx context.Context, opts ...*options.FindOptions) ([]T, error) {
+	return r.findAll(ctx, "FindAllWith", opts...)
+}
+
+// FindFirst returns the first document by sort, or ErrNotFound when there are no documents
+func (r *MongoRepository[T]) FindFirst(ctx context.Context, sort bson.D) (T, error) {
+	return r.findFirst(ctx, "FindFirst", sort)
+}
+
+// FindLast returns the last document by sort, reversing its directions, or ErrNotFound when there are no documents
+func (r *MongoRepository[T]) FindLast(ctx context.Context, sort bson.D) (T, error) {
+	reversed, err := reverseSort(sort)
+	if err != nil {
+		var result T
+		return result, err
 	}
+	return r.findFirst(ctx, "FindLast", reversed)
+}
 
-	_, err := r.collection.BulkWrite(context.TODO(), writes)
+func (r *MongoRepository[T]) findFirst(ctx context.Context, op string, sort bson.D) (T, error) {
+	var result T
+	results, err := r.findAll(ctx, op, options.Find().SetSort(sort).SetLimit(1))
 	if err != nil {
-		return items, err
+		return result, err
 	}
-	return items, nil
+	if len(results) == 0 {
+		return result, ErrNotFound
+	}
+	return results[0], nil
 }
 
-func (r *MongoRepository[T]) DeleteById(id primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(context.TODO(), bson.M{"_id": id})
-	return err
+func reverseSort(sort bson.D) (bson.D, error) {
+	reversed := make(bson.D, len(sort))
+	for i, e := range sort {
+		switch order := e.Value.(type) {
+		case int:
+			reversed[i] = bson.E{Key: e.Key, Value: -order}
+		case int32:
+			reversed[i] = bson.E{Key: e.Key, Value: -order}
+		case int64:
+			reversed[i] = bson.E{Key: e.Key, Value: -order}
+		case float64:
+			reversed[i] = bson.E{Key: e.Key, Value: -order}
+		default:
+			return nil, fmt.Errorf("cannot reverse sort order %v of %s", e.Value, e.Key)
+		}
+	}
+	return reversed, nil
 }
 
-func (r *MongoRepository[T]) Delete(query *QueryBuilder[T]) (int64, error) {
-	res, err := r.collection.DeleteMany(query.context, query.filter)
+func (r *MongoRepository[T]) findAll(ctx context.Context, op string, opts ...*options.FindOptions) ([]T, error) {
+	var results []T
+	collection, err := r.readCollection(nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return res.DeletedCount, nil
+	if r.defaultSort != nil {
+		// options later in opts override the default sort
+		opts = append([]*options.FindOptions{options.Find().SetSort(r.defaultSort)}, opts...)
+	}
+	filter := r.scoped(bson.M{})
+	err = r.execute(ctx, op, filter, func(ctx context.Context) error {
+		cursor, err := collection.Find(ctx, filter, opts...)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
+	return results, err
 }
 
-func (r *MongoRepository[T]) QueryOne(query *QueryBuilder[T]) (T, error) {
+func (r *MongoRepository[T]) FindById(ctx context.Context, id interface{}) (T, error) {
 	var result T
-	findOptions := options.FindOne()
-	if query.projection != nil {
-		findOptions.SetProjection(query.projection)
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return result, err
 	}
-	dafaq := r.collection.FindOne(context.TODO(), bson.M{"name": "Query Test"})
-	err := dafaq.Decode(&result)
+	filter := r.scoped(bson.M{"_id": id})
+	err = r.execute(ctx, "FindById", filter, func(ctx context.Context) error {
+		return collection.FindOne(ctx, filter).Decode(&result)
+	})
 	return result, err
 }
 
-func (r *MongoRepository[T]) QueryMany(query *QueryBuilder[T]) ([]T, error) {
-	var results []T
-	findOptions := options.Find()
-	if query.sort != nil {
-		findOptions.SetSort(query.sort)
-	}
-	if query.projection != nil {
-		findOptions.SetProjection(query.projection)
+// FindByHexId is like FindById for the hex form of an ObjectID, such as one taken from a URL,
+// returning an error wrapping ErrInvalidID when hex isn't a valid ObjectID
+func (r *MongoRepository[T]) FindByHexId(ctx context.Context, hex string) (T, error) {
+	id, err := parseHexId(hex)
+	if err != nil {
+		var result T
+		return result, err
 	}
-	if len(query.pageable) == 2 {
-		findOptions.SetSkip(int64(query.pageable[1] * query.pageable[0]))
-		findOptions.SetLimit(int64(query.pageable[1]))
+	return r.FindById(ctx, id)
+}
+
+// ExistsByHexId is like ExistsById for the hex form of an ObjectID, see FindByHexId
+func (r *MongoRepository[T]) ExistsByHexId(ctx context.Context, hex string) (bool, error) {
+	id, err := parseHexId(hex)
+	if err != nil {
+		return false, err
 	}
-	cursor, err := r.collection.Find(query.context, query.filter, findOptions)
+	return r.ExistsById(ctx, id)
+}
+
+// DeleteByHexId is like DeleteById for the hex form of an ObjectID, see FindByHexId
+func (r *MongoRepository[T]) DeleteByHexId(ctx context.Context, hex string) error {
+	id, err := parseHexId(hex)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer cursor.Close(query.context)
-	err = cursor.All(query.context, &results)
-	return results, err
+	return r.DeleteById(ctx, id)
 }
 
-func (r *MongoRepository[T]) AggregateOne(ctx context.Context, pipeline []bson.M) (bson.M, error) {
-	cursor, err := r.collection.Aggregate(ctx, pipeline)
+func parseHexId(hex string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(hex)
 	if err != nil {
-		return nil, err
+		return id, fmt.Errorf("%w: %q is not an ObjectID", ErrInvalidID, hex)
 	}
-	defer cursor.Close(ctx)
-	var result bson.M
-	if cursor.Next(ctx) {
-		err = cursor.Decode(&result)
+	return id, nil
+}
+
+// WithDefaultSort returns a copy of the repository sorting the results of FindAll & of queries
+// without a sort of their own by sort
+func (r *MongoRepository[T]) WithDefaultSort(sort bson.D) *MongoRepository[T] {
+	clone := r.clone()
+	clone.defaultSort = sort
+	return clone
+}
+
+const defaultIdChunkSize = 1000
+
+// WithIdChunkSize returns a copy of the repository whose FindByIds queries at most size ids at a
+// time, 1000 by default, keeping large id lists under the BSON document size limit
+func (r *MongoRepository[T]) WithIdChunkSize(size int) *MongoRepository[T] {
+	clone := r.clone()
+	clone.idChunkSize = size
+	return clone
+}
+
+func (r *MongoRepository[T]) FindByIds(ctx context.Context, ids []primitive.ObjectID) ([]T, error) {
+	if len(ids) == 0 {
+		return []T{}, nil
+	}
+	chunkSize := r.idChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultIdChunkSize
+	}
+	var results []T
+	for start := 0; start < len(ids); start += chunkSize {
+		end := min(start+chunkSize, len(ids))
+		chunk, err := r.findByIds(ctx, ids[start:end])
 		if err != nil {
 			return nil, err
 		}
+		results = append(results, chunk...)
 	}
-	return result, nil
+	return results, nil
 }
 
-func (r *MongoRepository[T]) AggregateMultiple(ctx context.Context, pipeline []bson.M) ([]bson.M, error) {
-	cursor, err := r.collection.Aggregate(ctx, pipeline)
+func (r *MongoRepository[T]) findByIds(ctx context.Context, ids []primitive.ObjectID) ([]T, error) {
+	var results []T
+	collection, err := r.readCollection(nil)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
-	var results []bson.M
-	err = cursor.All(ctx, &results)
+	filter := r.scoped(bson.M{"_id": bson.M{"$in": ids}})
+	err = r.execute(ctx, "FindByIds", filter, func(ctx context.Context) error {
+		cursor, err := collection.Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
+	return results, err
+}
+
+// FindByIdsOrdered returns the documents in the order of ids, skipping ids that
+// were not found. An id requested more than once is returned once per request.
+func (r *MongoRepository[T]) FindByIdsOrdered(ctx context.Context, ids []primitive.ObjectID) ([]T, error) {
+	found, err := r.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[interface{}]T, len(found))
+	for i := range found {
+		byId[getId(&found[i], r.idFieldIndex)] = found[i]
+	}
+	results := make([]T, 0, len(ids))
+	for _, id := range ids {
+		if item, ok := byId[id]; ok {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+func (r *MongoRepository[T]) ExistsById(ctx context.Context, id interface{}) (bool, error) {
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	filter := r.scoped(bson.M{"_id": id})
+	err = r.execute(ctx, "ExistsById", filter, func(ctx context.Context) (err error) {
+		count, err = collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *MongoRepository[T]) CountAll(ctx context.Context) (int64, error) {
+	return r.countAll(ctx)
+}
+
+func (r *MongoRepository[T]) countAll(ctx context.Context) (int64, error) {
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	filter := r.scoped(bson.M{})
+	err = r.execute(ctx, "CountAll", filter, func(ctx context.Context) (err error) {
+		count, err = collection.CountDocuments(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// EstimatedCount reads the document count from collection metadata instead of
+// scanning, so it is fast but may be inaccurate under concurrent writes. Scoped
+// repositories fall back to an exact count, as metadata covers the whole collection.
+func (r *MongoRepository[T]) EstimatedCount(ctx context.Context) (int64, error) {
+	if len(r.scope) > 0 {
+		return r.countAll(ctx)
+	}
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = r.execute(ctx, "EstimatedCount", nil, func(ctx context.Context) (err error) {
+		count, err = collection.EstimatedDocumentCount(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *MongoRepository[T]) Count(ctx context.Context, query *QueryBuilder[T]) (int64, error) {
+	collection, err := r.readCollection(query)
+	if err != nil {
+		return 0, err
+	}
+	countOptions := options.Count()
+	if query.hint != nil {
+		countOptions.SetHint(query.hint)
+	}
+	if query.maxTime > 0 {
+		countOptions.SetMaxTime(query.maxTime)
+	}
+	var count int64
+	filter := r.queryFilter(query)
+	err = r.execute(ctx, "Count", filter, func(ctx context.Context) (err error) {
+		count, err = collection.CountDocuments(ctx, filter, countOptions)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+type SaveResult struct {
+	Inserted     bool
+	MatchedCount int64
+	UpsertedID   interface{}
+}
+
+func (r *MongoRepository[T]) Save(ctx context.Context, item T) (T, error) {
+	item, _, err := r.save(ctx, item)
+	return item, err
+}
+
+func (r *MongoRepository[T]) SaveWithResult(ctx context.Context, item T) (T, SaveResult, error) {
+	return r.save(ctx, item)
+}
+
+func (r *MongoRepository[T]) save(ctx context.Context, item T) (T, SaveResult, error) {
+	var result SaveResult
+	if err := beforeSave(ctx, &item); err != nil {
+		return item, result, err
+	}
+	if err := r.stampScope(&item); err != nil {
+		return item, result, err
+	}
+	if err := validate(&item); err != nil {
+		return item, result, err
+	}
+	id, err := r.ensureId(&item)
+	if err != nil {
+		return item, result, err
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return item, result, err
+	}
+	var res *mongo.UpdateResult
+	filter := r.scoped(bson.M{"_id": id})
+	err = r.execute(ctx, "Save", filter, func(ctx context.Context) (err error) {
+		res, err = collection.ReplaceOne(ctx, filter, item, options.Replace().SetUpsert(true))
+		return err
+	})
+	if err != nil {
+		return item, result, wrapWriteError(err)
+	}
+	if res != nil {
+		result = SaveResult{
+			Inserted:     res.UpsertedCount > 0,
+			MatchedCount: res.MatchedCount,
+			UpsertedID:   res.UpsertedID,
+		}
+	}
+	if err := afterSave(ctx, &item); err != nil {
+		return item, result, err
+	}
+	return item, result, nil
+}
+
+// UpsertByFilter replaces the document matching filter with item, inserting it when nothing
+// matches. An unset id is left for the server to generate & populated back when inserted.
+func (r *MongoRepository[T]) UpsertByFilter(ctx context.Context, filter bson.M, item T) (T, bool, error) {
+	if err := beforeSave(ctx, &item); err != nil {
+		return item, false, err
+	}
+	if err := r.stampScope(&item); err != nil {
+		return item, false, err
+	}
+	if err := validate(&item); err != nil {
+		return item, false, err
+	}
+
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return item, false, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return item, false, err
+	}
+	idField := reflect.ValueOf(&item).Elem().Field(r.idFieldIndex)
+	if idField.IsZero() {
+		// a zero id would overwrite the immutable _id of the matched document
+		replacement := bson.D{}
+		for _, elem := range doc {
+			if elem.Key != "_id" {
+				replacement = append(replacement, elem)
+			}
+		}
+		doc = replacement
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return item, false, err
+	}
+	var res *mongo.UpdateResult
+	filter = r.scoped(filter)
+	err = r.execute(ctx, "UpsertByFilter", filter, func(ctx context.Context) (err error) {
+		res, err = collection.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+		return err
+	})
+	if err != nil {
+		return item, false, wrapWriteError(err)
+	}
+	inserted := res != nil && res.UpsertedCount > 0
+	if inserted && res.UpsertedID != nil {
+		upsertedId := reflect.ValueOf(res.UpsertedID)
+		if upsertedId.Type().AssignableTo(idField.Type()) {
+			idField.Set(upsertedId)
+		}
+	}
+	if err := afterSave(ctx, &item); err != nil {
+		return item, inserted, err
+	}
+	return item, inserted, nil
+}
+
+// Upsert updates the document matching filter with set, inserting it when none match. Fields of
+// setOnInsert are only written on insert, such as a creation date. It returns the document as
+// it is after the write.
+func (r *MongoRepository[T]) Upsert(ctx context.Context, filter bson.M, set bson.M, setOnInsert bson.M) (T, error) {
+	var result T
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(setOnInsert) > 0 {
+		update["$setOnInsert"] = setOnInsert
+	}
+	if len(update) == 0 {
+		return result, errors.New("no fields to upsert")
+	}
+	if err := r.checkScopeFields(updateFields(update)...); err != nil {
+		return result, err
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return result, err
+	}
+	filter = r.scoped(filter)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = r.execute(ctx, "Upsert", filter, func(ctx context.Context) error {
+		return collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	})
+	return result, wrapWriteError(err)
+}
+
+// UpdateByIdAndReturn applies update to the document with the given id and returns the document
+// as it is after the update, or ErrNotFound. An update without operators is applied as a $set.
+func (r *MongoRepository[T]) UpdateByIdAndReturn(ctx context.Context, id interface{}, update bson.M) (T, error) {
+	var result T
+	if len(update) == 0 {
+		return result, errors.New("no fields to update")
+	}
+	if !hasOperator(update) {
+		update = bson.M{"$set": update}
+	}
+	if err := r.checkScopeFields(updateFields(update)...); err != nil {
+		return result, err
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return result, err
+	}
+	filter := r.scoped(bson.M{"_id": id})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.execute(ctx, "UpdateByIdAndReturn", filter, func(ctx context.Context) error {
+		return collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, ErrNotFound
+	}
+	return result, wrapWriteError(err)
+}
+
+func hasOperator(update bson.M) bool {
+	for key := range update {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveFields persists only the named bson fields of item onto the document with the given id,
+// leaving its other fields as they are, so that concurrent changes to them aren't overwritten.
+// It returns ErrNotFound when no document has the id. Fields of the scope can't be saved, as
+// that would move the document out of it.
+func (r *MongoRepository[T]) SaveFields(ctx context.Context, id primitive.ObjectID, item T, fields ...string) error {
+	if len(fields) == 0 {
+		return errors.New("no fields to save")
+	}
+	if err := r.checkScopeFields(fields...); err != nil {
+		return err
+	}
+	if err := beforeSave(ctx, &item); err != nil {
+		return err
+	}
+	if err := validate(&item); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(&item).Elem()
+	set := bson.M{}
+	for _, name := range fields {
+		field, ok := fieldByBsonName(v, name)
+		if !ok {
+			return fmt.Errorf("field %s is not part of %s", name, v.Type())
+		}
+		set[name] = field.Interface()
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return err
+	}
+	var res *mongo.UpdateResult
+	filter := r.scoped(bson.M{"_id": id})
+	err = r.execute(ctx, "SaveFields", filter, func(ctx context.Context) (err error) {
+		res, err = collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+		return err
+	})
+	if err != nil {
+		return wrapWriteError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return afterSave(ctx, &item)
+}
+
+func (r *MongoRepository[T]) SaveAll(ctx context.Context, items []T) ([]T, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	var writes []mongo.WriteModel
+	for i := range items {
+		if err := beforeSave(ctx, &items[i]); err != nil {
+			return items, err
+		}
+		if err := r.stampScope(&items[i]); err != nil {
+			return items, err
+		}
+		if err := validate(&items[i]); err != nil {
+			return items, err
+		}
+		id, err := r.ensureId(&items[i])
+		if err != nil {
+			return items, err
+		}
+
+		write := mongo.NewReplaceOneModel().
+			SetFilter(r.scoped(bson.M{"_id": id})).
+			SetReplacement(items[i]).
+			SetUpsert(true)
+		writes = append(writes, write)
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return items, err
+	}
+	err = r.execute(ctx, "SaveAll", nil, func(ctx context.Context) error {
+		_, err := collection.BulkWrite(ctx, writes)
+		return err
+	})
+	if err != nil {
+		return items, wrapWriteError(err)
+	}
+	for i := range items {
+		if err := afterSave(ctx, &items[i]); err != nil {
+			return items, err
+		}
+	}
+	return items, nil
+}
+
+type ItemResult struct {
+	ID      interface{}
+	Success bool
+	Err     error
+}
+
+// SaveAllResult upserts items like SaveAll, but unordered so that a failing item doesn't stop the
+// others, reporting the outcome of every item at the same index as the item.
+func (r *MongoRepository[T]) SaveAllResult(ctx context.Context, items []T) ([]ItemResult, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	results := make([]ItemResult, len(items))
+	var writes []mongo.WriteModel
+	var writeIndexes []int
+	for i := range items {
+		err := beforeSave(ctx, &items[i])
+		if err == nil {
+			err = r.stampScope(&items[i])
+		}
+		if err == nil {
+			err = validate(&items[i])
+		}
+		var id interface{}
+		if err == nil {
+			id, err = r.ensureId(&items[i])
+		}
+		results[i] = ItemResult{ID: id, Err: err}
+		if err != nil {
+			continue
+		}
+
+		write := mongo.NewReplaceOneModel().
+			SetFilter(r.scoped(bson.M{"_id": id})).
+			SetReplacement(items[i]).
+			SetUpsert(true)
+		writes = append(writes, write)
+		writeIndexes = append(writeIndexes, i)
+	}
+
+	if len(writes) > 0 {
+		collection, err := r.writeCollection(nil)
+		if err != nil {
+			return results, err
+		}
+		err = r.execute(ctx, "SaveAllResult", nil, func(ctx context.Context) error {
+			_, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+			return err
+		})
+		var bulkErr mongo.BulkWriteException
+		if err != nil && !errors.Is(err, mongo.ErrUnacknowledgedWrite) && !errors.As(err, &bulkErr) {
+			for _, i := range writeIndexes {
+				results[i].Err = wrapWriteError(err)
+			}
+			return results, wrapWriteError(err)
+		}
+		// write errors are indexed by the position of the write, not of the item
+		for _, writeErr := range bulkErr.WriteErrors {
+			i := writeIndexes[writeErr.Index]
+			results[i].Err = wrapWriteError(mongo.WriteException{WriteErrors: mongo.WriteErrors{writeErr.WriteError}})
+		}
+	}
+
+	failed := 0
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = afterSave(ctx, &items[i])
+		}
+		results[i].Success = results[i].Err == nil
+		if !results[i].Success {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("failed to save %d of %d items", failed, len(items))
+	}
+	return results, nil
+}
+
+func (r *MongoRepository[T]) InsertMany(ctx context.Context, items []T, ordered bool) ([]T, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	docs := make([]interface{}, len(items))
+	for i := range items {
+		if err := beforeSave(ctx, &items[i]); err != nil {
+			return nil, err
+		}
+		if err := r.stampScope(&items[i]); err != nil {
+			return nil, err
+		}
+		if err := validate(&items[i]); err != nil {
+			return nil, err
+		}
+		if _, err := r.ensureId(&items[i]); err != nil {
+			return nil, err
+		}
+		docs[i] = items[i]
+	}
+
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	err = r.execute(ctx, "InsertMany", nil, func(ctx context.Context) error {
+		_, err := collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(ordered))
+		return err
+	})
+	if err == nil || errors.Is(err, mongo.ErrUnacknowledgedWrite) {
+		return items, afterSaveAll(ctx, items)
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return nil, wrapWriteError(err)
+	}
+
+	// ordered inserts stop at the first failure, unordered ones only skip the failed items
+	failed := make(map[int]bool, len(bulkErr.WriteErrors))
+	stopAt := len(items)
+	for _, writeErr := range bulkErr.WriteErrors {
+		failed[writeErr.Index] = true
+		if ordered && writeErr.Index < stopAt {
+			stopAt = writeErr.Index
+		}
+	}
+	var inserted []T
+	for i := 0; i < stopAt; i++ {
+		if !failed[i] {
+			inserted = append(inserted, items[i])
+		}
+	}
+	err = fmt.Errorf("failed to insert %d of %d items: %w", len(items)-len(inserted), len(items), wrapWriteError(err))
+	return inserted, errors.Join(err, afterSaveAll(ctx, inserted))
+}
+
+func (r *MongoRepository[T]) ensureId(item *T) (interface{}, error) {
+	return ensureId(item, r.idFieldIndex)
+}
+
+func getId[T any](item *T, idFieldIndex int) interface{} {
+	return reflect.ValueOf(item).Elem().Field(idFieldIndex).Interface()
+}
+
+var objectIdType = reflect.TypeOf(primitive.ObjectID{})
+
+// ensureId generates an ObjectID for unset ObjectID ids, other id types including composite
+// struct ids must be set by the caller, a zero struct being treated as unset
+func ensureId[T any](item *T, idFieldIndex int) (interface{}, error) {
+	idField := reflect.ValueOf(item).Elem().Field(idFieldIndex)
+	if !idField.IsZero() {
+		return idField.Interface(), nil
+	}
+	if idField.Kind() == reflect.Struct && idField.Type() != objectIdType {
+		return nil, fmt.Errorf("%w: composite id of type %s must be set", ErrMissingId, idField.Type())
+	}
+	if idField.Type() != objectIdType {
+		return nil, fmt.Errorf("%w: cannot generate an id of type %s", ErrMissingId, idField.Type())
+	}
+	id := primitive.NewObjectID()
+	idField.Set(reflect.ValueOf(id))
+	return id, nil
+}
+
+func (r *MongoRepository[T]) DeleteById(ctx context.Context, id interface{}) error {
+	filter := r.scoped(bson.M{"_id": id})
+	return r.execute(ctx, "DeleteById", filter, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, filter)
+		return err
+	})
+}
+
+func (r *MongoRepository[T]) DeleteAll(ctx context.Context) (int64, error) {
+	var res *mongo.DeleteResult
+	filter := r.scoped(bson.M{})
+	err := r.execute(ctx, "DeleteAll", filter, func(ctx context.Context) (err error) {
+		res, err = r.collection.DeleteMany(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// DeleteInBatches deletes the documents matching filter batchSize at a time, so that removing a
+// large number of documents doesn't stall the server, returning the total deleted. Cancelling
+// ctx stops it between batches, the batches already deleted staying deleted.
+func (r *MongoRepository[T]) DeleteInBatches(ctx context.Context, filter bson.M, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return 0, err
+	}
+	filter = r.scoped(filter)
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(int64(batchSize))
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		var batch []struct {
+			ID interface{} `bson:"_id"`
+		}
+		err := r.execute(ctx, "DeleteInBatches", filter, func(ctx context.Context) error {
+			cursor, err := collection.Find(ctx, filter, opts)
+			if err != nil {
+				return err
+			}
+			return cursor.All(ctx, &batch)
+		})
+		if err != nil || len(batch) == 0 {
+			return total, err
+		}
+		ids := make(bson.A, len(batch))
+		for i, doc := range batch {
+			ids[i] = doc.ID
+		}
+		var res *mongo.DeleteResult
+		idFilter := bson.M{"_id": bson.M{"$in": ids}}
+		err = r.execute(ctx, "DeleteInBatches", idFilter, func(ctx context.Context) (err error) {
+			res, err = collection.DeleteMany(ctx, idFilter)
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		total += res.DeletedCount
+	}
+}
+
+// EachPage calls fn with the documents matching filter size at a time in _id order, until fn
+// returns an error or the documents are exhausted. Pages continue after the last _id seen rather
+// than skipping an offset, so documents inserted or deleted meanwhile don't shift the pages.
+func (r *MongoRepository[T]) EachPage(ctx context.Context, filter bson.M, size int, fn func(page []T) error) error {
+	if size <= 0 {
+		return fmt.Errorf("page size must be positive, got %d", size)
+	}
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(size))
+	var lastId interface{}
+	for {
+		pageFilter := filter
+		if lastId != nil {
+			pageFilter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": lastId}}}}
+		}
+		pageFilter = r.scoped(pageFilter)
+		var page []T
+		err := r.execute(ctx, "EachPage", pageFilter, func(ctx context.Context) error {
+			cursor, err := collection.Find(ctx, pageFilter, opts)
+			if err != nil {
+				return err
+			}
+			return cursor.All(ctx, &page)
+		})
+		if err != nil || len(page) == 0 {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if len(page) < size {
+			return nil
+		}
+		lastId = getId(&page[len(page)-1], r.idFieldIndex)
+	}
+}
+
+// RenameField renames the from field to to in every document of the collection, returning the
+// number of documents modified. It rewrites every document, so it should be run off-peak.
+func (r *MongoRepository[T]) RenameField(ctx context.Context, from, to string) (int64, error) {
+	if err := r.checkScopeFields(from, to); err != nil {
+		return 0, err
+	}
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return 0, err
+	}
+	var res *mongo.UpdateResult
+	filter := r.scoped(bson.M{from: bson.M{"$exists": true}})
+	err = r.execute(ctx, "RenameField", filter, func(ctx context.Context) (err error) {
+		res, err = collection.UpdateMany(ctx, filter, bson.M{"$rename": bson.M{from: to}})
+		return err
+	})
+	if err != nil || res == nil {
+		return 0, wrapWriteError(err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// Backfill sets the fields of set on the documents matching filter, such as those missing a newly
+// introduced field, returning the number of documents modified. It is meant for one off migrations.
+func (r *MongoRepository[T]) Backfill(ctx context.Context, set bson.M, filter bson.M) (int64, error) {
+	if len(set) == 0 {
+		return 0, fmt.Errorf("backfill requires at least one field to set")
+	}
+	if err := r.checkScopeFields(updateFields(set)...); err != nil {
+		return 0, err
+	}
+	collection, err := r.writeCollection(nil)
+	if err != nil {
+		return 0, err
+	}
+	var res *mongo.UpdateResult
+	filter = r.scoped(filter)
+	err = r.execute(ctx, "Backfill", filter, func(ctx context.Context) (err error) {
+		res, err = collection.UpdateMany(ctx, filter, bson.M{"$set": set})
+		return err
+	})
+	if err != nil || res == nil {
+		return 0, wrapWriteError(err)
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r *MongoRepository[T]) Delete(ctx context.Context, query *QueryBuilder[T]) (int64, error) {
+	var res *mongo.DeleteResult
+	filter := r.queryFilter(query)
+	err := r.execute(ctx, "Delete", filter, func(ctx context.Context) (err error) {
+		res, err = r.queryCollection(query).DeleteMany(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *MongoRepository[T]) Unset(ctx context.Context, query *QueryBuilder[T], fields ...string) (int64, error) {
+	if err := r.checkScopeFields(fields...); err != nil {
+		return 0, err
+	}
+	unset := bson.M{}
+	for _, field := range fields {
+		unset[field] = ""
+	}
+	collection, err := r.writeCollection(query)
+	if err != nil {
+		return 0, err
+	}
+	var res *mongo.UpdateResult
+	filter := r.queryFilter(query)
+	err = r.execute(ctx, "Unset", filter, func(ctx context.Context) (err error) {
+		res, err = collection.UpdateMany(ctx, filter, bson.M{"$unset": unset})
+		return err
+	})
+	if err != nil || res == nil {
+		return 0, wrapWriteError(err)
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r *MongoRepository[T]) QueryOne(ctx context.Context, query *QueryBuilder[T]) (T, error) {
+	var result T
+	findOptions := options.FindOne()
+	if query.sort != nil {
+		findOptions.SetSort(query.sort)
+	} else if r.defaultSort != nil {
+		findOptions.SetSort(r.defaultSort)
+	}
+	if skip := query.pageable[0] * query.pageable[1]; skip > 0 {
+		findOptions.SetSkip(int64(skip))
+	}
+	if query.projection != nil {
+		findOptions.SetProjection(query.projection)
+	}
+	if query.hint != nil {
+		findOptions.SetHint(query.hint)
+	}
+	if query.maxTime > 0 {
+		findOptions.SetMaxTime(query.maxTime)
+	}
+	collection, err := r.readCollection(query)
+	if err != nil {
+		return result, err
+	}
+	filter := r.queryFilter(query)
+	err = r.execute(ctx, "QueryOne", filter, func(ctx context.Context) error {
+		return collection.FindOne(ctx, filter, findOptions).Decode(&result)
+	})
+	return result, err
+}
+
+func (r *MongoRepository[T]) QueryMany(ctx context.Context, query *QueryBuilder[T]) ([]T, error) {
+	var results []T
+	_, err := r.find(ctx, "QueryMany", query, &results)
+	return results, err
+}
+
+// QueryD finds the documents matching an ordered filter, passed to the driver as is
+func (r *MongoRepository[T]) QueryD(ctx context.Context, filter bson.D) ([]T, error) {
+	return r.QueryMany(ctx, r.QueryRunner().FilterD(filter))
+}
+
+// QueryChan streams the documents matching the query onto the returned channel as the
+// cursor yields them. Both channels are closed once the cursor is exhausted, an error
+// occurs or ctx is cancelled, in which case ctx.Err() is sent on the error channel.
+func (r *MongoRepository[T]) QueryChan(ctx context.Context, query *QueryBuilder[T]) (<-chan T, <-chan error) {
+	results := make(chan T)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(results)
+
+		cursor, err := r.find(ctx, "QueryChan", query, nil)
+		if err != nil {
+			errs <- err
+			return
+		}
+		// the cursor is closed with a fresh context so it is released even after ctx is cancelled
+		defer cursor.Close(context.Background())
+
+		for cursor.Next(ctx) {
+			var item T
+			if err := cursor.Decode(&item); err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case results <- item:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			errs <- err
+		}
+	}()
+	return results, errs
+}
+
+// Explain returns the plan & execution stats the server reports for the query, which shows
+// whether its filter & sort are served by an index.
+func (r *MongoRepository[T]) Explain(ctx context.Context, query *QueryBuilder[T]) (bson.M, error) {
+	collection := r.queryCollection(query)
+	find := bson.D{{Key: "find", Value: collection.Name()}}
+	filter := r.queryFilter(query)
+	if filter != nil {
+		find = append(find, bson.E{Key: "filter", Value: filter})
+	}
+	if query.sort != nil {
+		find = append(find, bson.E{Key: "sort", Value: query.sort})
+	}
+	if query.projection != nil {
+		find = append(find, bson.E{Key: "projection", Value: query.projection})
+	}
+	if query.hint != nil {
+		find = append(find, bson.E{Key: "hint", Value: query.hint})
+	}
+	if query.maxTime > 0 {
+		find = append(find, bson.E{Key: "maxTimeMS", Value: query.maxTime.Milliseconds()})
+	}
+	if query.pageable[1] > 0 {
+		find = append(find,
+			bson.E{Key: "skip", Value: int64(query.pageable[1] * query.pageable[0])},
+			bson.E{Key: "limit", Value: int64(query.pageable[1])},
+		)
+	}
+	command := bson.D{{Key: "explain", Value: find}, {Key: "verbosity", Value: "executionStats"}}
+
+	var plan bson.M
+	err := r.execute(ctx, "Explain", filter, func(ctx context.Context) error {
+		return collection.Database().RunCommand(ctx, command).Decode(&plan)
+	})
+	return plan, err
+}
+
+// find runs the query, decoding every document into results within the operation so that the
+// default timeout also bounds the getMores. A nil results returns the open cursor instead, for
+// callers streaming the documents.
+func (r *MongoRepository[T]) find(ctx context.Context, op string, query *QueryBuilder[T], results interface{}) (*mongo.Cursor, error) {
+	if query.addFields != nil {
+		return r.findAggregate(ctx, op, query, results)
+	}
+	findOptions := options.Find()
+	if query.sort != nil {
+		findOptions.SetSort(query.sort)
+	} else if r.defaultSort != nil {
+		findOptions.SetSort(r.defaultSort)
+	}
+	if query.projection != nil {
+		findOptions.SetProjection(query.projection)
+	}
+	if len(query.pageable) == 2 {
+		findOptions.SetSkip(int64(query.pageable[1] * query.pageable[0]))
+		findOptions.SetLimit(int64(query.pageable[1]))
+	}
+	if query.batchSize > 0 {
+		findOptions.SetBatchSize(int32(query.batchSize))
+	}
+	if query.hint != nil {
+		findOptions.SetHint(query.hint)
+	}
+	if query.maxTime > 0 {
+		findOptions.SetMaxTime(query.maxTime)
+	}
+	collection, err := r.readCollection(query)
+	if err != nil {
+		return nil, err
+	}
+	var cursor *mongo.Cursor
+	filter := r.queryFilter(query)
+	err = r.execute(ctx, op, filter, func(ctx context.Context) (err error) {
+		cursor, err = collection.Find(ctx, filter, findOptions)
+		if err != nil || results == nil {
+			return err
+		}
+		return cursor.All(ctx, results)
+	})
+	return cursor, err
+}
+
+// findAggregate runs the query as an aggregation, as computed fields aren't available to finds
+func (r *MongoRepository[T]) findAggregate(ctx context.Context, op string, query *QueryBuilder[T], results interface{}) (*mongo.Cursor, error) {
+	filter := r.queryFilter(query)
+	pipeline := []bson.M{{"$match": filter}, {"$addFields": query.addFields}}
+	if query.sort != nil {
+		pipeline = append(pipeline, bson.M{"$sort": query.sort})
+	} else if r.defaultSort != nil {
+		pipeline = append(pipeline, bson.M{"$sort": r.defaultSort})
+	}
+	if query.pageable[1] > 0 {
+		pipeline = append(pipeline,
+			bson.M{"$skip": query.pageable[1] * query.pageable[0]},
+			bson.M{"$limit": query.pageable[1]})
+	}
+	if query.projection != nil {
+		pipeline = append(pipeline, bson.M{"$project": query.projection})
+	}
+
+	aggregateOptions := options.Aggregate()
+	if query.batchSize > 0 {
+		aggregateOptions.SetBatchSize(int32(query.batchSize))
+	}
+	if query.hint != nil {
+		aggregateOptions.SetHint(query.hint)
+	}
+	if query.maxTime > 0 {
+		aggregateOptions.SetMaxTime(query.maxTime)
+	}
+	collection, err := r.readCollection(query)
+	if err != nil {
+		return nil, err
+	}
+	var cursor *mongo.Cursor
+	err = r.execute(ctx, op, pipeline, func(ctx context.Context) (err error) {
+		cursor, err = collection.Aggregate(ctx, pipeline, aggregateOptions)
+		if err != nil || results == nil {
+			return err
+		}
+		return cursor.All(ctx, results)
+	})
+	return cursor, err
+}
+
+// AggregateOne returns the first result of pipeline, or ErrNotFound when it has no results
+func (r *MongoRepository[T]) AggregateOne(ctx context.Context, pipeline []bson.M) (bson.M, error) {
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var result bson.M
+	found := false
+	pipeline = r.scopedPipeline(pipeline)
+	err = r.execute(ctx, "AggregateOne", pipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		if found = cursor.Next(ctx); !found {
+			return cursor.Err()
+		}
+		return cursor.Decode(&result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+func (r *MongoRepository[T]) AggregateMultiple(ctx context.Context, pipeline []bson.M) ([]bson.M, error) {
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []bson.M
+	pipeline = r.scopedPipeline(pipeline)
+	err = r.execute(ctx, "AggregateMultiple", pipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
+	return results, err
+}
+
+// AggregateTyped decodes the results of pipeline into T, for pipelines that keep the shape of the documents
+func (r *MongoRepository[T]) AggregateTyped(ctx context.Context, pipeline []bson.M) ([]T, error) {
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []T
+	pipeline = r.scopedPipeline(pipeline)
+	err = r.execute(ctx, "AggregateTyped", pipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
 	return results, err
 }
@@ -2,6 +2,10 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,28 +13,39 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type TestModel struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" cindex:"{name:1,age:1};{age:1,created_at:1}"`
 	Name      string             `bson:"name" index:"1, unique"`
-	Age       int                `bson:"age" index:"-1, text"`
+	Age       int                `bson:"age" index:"-1"`
 	CreatedAt time.Time          `bson:"created_at" index:"1, sparse"`
 }
 
-func setupTestRepo(t *testing.T) *MongoRepository[TestModel] {
+type AccountModel struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Email string             `bson:"email" index:"1, unique"`
+}
+
+func setupTestCollection(t *testing.T, name string) *mongo.Collection {
 	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI("mongodb://localhost:27017/testdb"))
 	if err != nil {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
-	collection := client.Database("testdb").Collection("testcollection")
+	collection := client.Database("testdb").Collection(name)
 
 	err = collection.Drop(context.TODO())
 	if err != nil {
 		t.Fatalf("Failed to drop collection: %v", err)
 	}
+	return collection
+}
 
-	repo, err := NewMongoRepository[TestModel](collection)
+func setupTestRepo(t *testing.T) *MongoRepository[TestModel] {
+	repo, err := NewMongoRepository[TestModel](setupTestCollection(t, "testcollection"))
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -41,7 +56,7 @@ func TestSave(t *testing.T) {
 	repo := setupTestRepo(t)
 
 	newItem := TestModel{Name: "John Doe", Age: 30, CreatedAt: time.Now()}
-	savedItem, err := repo.Save(newItem)
+	savedItem, err := repo.Save(context.TODO(), newItem)
 	if err != nil {
 		t.Fatalf("Failed to save new item: %v", err)
 	}
@@ -50,7 +65,7 @@ func TestSave(t *testing.T) {
 	}
 
 	savedItem.Name = "Jane Doe"
-	updatedItem, err := repo.Save(savedItem)
+	updatedItem, err := repo.Save(context.TODO(), savedItem)
 	if err != nil {
 		t.Fatalf("Failed to update item: %v", err)
 	}
@@ -58,7 +73,7 @@ func TestSave(t *testing.T) {
 		t.Fatalf("Expected updated name to be 'Jane Doe', got '%s'", updatedItem.Name)
 	}
 
-	count, err := repo.CountAll()
+	count, err := repo.CountAll(context.TODO())
 	if err != nil {
 		t.Fatalf("Failed to get count of items after saving: %v", err)
 	}
@@ -71,12 +86,12 @@ func TestFindById(t *testing.T) {
 	repo := setupTestRepo(t)
 
 	newItem := TestModel{Name: "Test User", Age: 25, CreatedAt: time.Now()}
-	savedItem, err := repo.Save(newItem)
+	savedItem, err := repo.Save(context.TODO(), newItem)
 	if err != nil {
 		t.Fatalf("Failed to save test item: %v", err)
 	}
 
-	foundItem, err := repo.FindById(savedItem.ID)
+	foundItem, err := repo.FindById(context.TODO(), savedItem.ID)
 	if err != nil {
 		t.Fatalf("Failed to find item by ID: %v", err)
 	}
@@ -85,6 +100,47 @@ func TestFindById(t *testing.T) {
 	}
 }
 
+func TestFindByHexId(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	savedItem, err := repo.Save(context.TODO(), TestModel{Name: "Hex User", Age: 25, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save test item: %v", err)
+	}
+
+	foundItem, err := repo.FindByHexId(context.TODO(), savedItem.ID.Hex())
+	if err != nil {
+		t.Fatalf("Failed to find item by hex id: %v", err)
+	}
+	if foundItem.ID != savedItem.ID {
+		t.Fatalf("Expected found item ID to match saved item ID")
+	}
+	exists, err := repo.ExistsByHexId(context.TODO(), savedItem.ID.Hex())
+	if err != nil || !exists {
+		t.Fatalf("Expected item to exist by hex id, got %v, %v", exists, err)
+	}
+	if err := repo.DeleteByHexId(context.TODO(), savedItem.ID.Hex()); err != nil {
+		t.Fatalf("Failed to delete item by hex id: %v", err)
+	}
+	exists, err = repo.ExistsByHexId(context.TODO(), savedItem.ID.Hex())
+	if err != nil || exists {
+		t.Fatalf("Expected deleted item not to exist, got %v, %v", exists, err)
+	}
+}
+
+func TestParseHexId(t *testing.T) {
+	id := primitive.NewObjectID()
+	parsed, err := parseHexId(id.Hex())
+	if err != nil || parsed != id {
+		t.Fatalf("Expected %s to parse, got %s, %v", id.Hex(), parsed.Hex(), err)
+	}
+	for _, hex := range []string{"", "not-an-id", id.Hex()[1:]} {
+		if _, err := parseHexId(hex); !errors.Is(err, ErrInvalidID) {
+			t.Fatalf("Expected %q to be rejected with ErrInvalidID, got %v", hex, err)
+		}
+	}
+}
+
 func TestFindByIds(t *testing.T) {
 	repo := setupTestRepo(t)
 	items := []TestModel{
@@ -93,14 +149,14 @@ func TestFindByIds(t *testing.T) {
 		{Name: "User 3", Age: 35, CreatedAt: time.Now()},
 	}
 
-	savedItems, err := repo.SaveAll(items)
+	savedItems, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Faild to save items: %v", err)
 	}
 
 	ids := []primitive.ObjectID{savedItems[0].ID, savedItems[1].ID}
 
-	foundItems, err := repo.FindByIds(ids)
+	foundItems, err := repo.FindByIds(context.TODO(), ids)
 	if err != nil {
 		t.Fatalf("Failed to find all items: %v", err)
 	}
@@ -109,6 +165,40 @@ func TestFindByIds(t *testing.T) {
 	}
 }
 
+func TestFindByIdsChunked(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := make([]TestModel, 2500)
+	for i := range items {
+		items[i] = TestModel{Name: fmt.Sprintf("Chunked %d", i), Age: i, CreatedAt: time.Now()}
+	}
+	savedItems, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	// every other id matches no document
+	ids := make([]primitive.ObjectID, 0, 5000)
+	for _, item := range savedItems {
+		ids = append(ids, item.ID, primitive.NewObjectID())
+	}
+
+	foundItems, err := repo.FindByIds(context.TODO(), ids)
+	if err != nil {
+		t.Fatalf("Failed to find items: %v", err)
+	}
+	if len(foundItems) != len(savedItems) {
+		t.Fatalf("Expected to find %d items, but found %d", len(savedItems), len(foundItems))
+	}
+
+	foundItems, err = repo.WithIdChunkSize(300).FindByIds(context.TODO(), ids)
+	if err != nil {
+		t.Fatalf("Failed to find items in chunks of 300: %v", err)
+	}
+	if len(foundItems) != len(savedItems) {
+		t.Fatalf("Expected to find %d items in chunks of 300, but found %d", len(savedItems), len(foundItems))
+	}
+}
+
 func CountAll(t *testing.T) {
 	repo := setupTestRepo(t)
 	items := []TestModel{
@@ -117,12 +207,12 @@ func CountAll(t *testing.T) {
 		{Name: "User 3", Age: 35, CreatedAt: time.Now()},
 	}
 
-	_, err := repo.SaveAll(items)
+	_, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Faild to save items: %v", err)
 	}
 
-	count, err := repo.CountAll()
+	count, err := repo.CountAll(context.TODO())
 	if err != nil {
 		t.Fatalf("Failed to find all items: %v", err)
 	}
@@ -139,12 +229,12 @@ func TestFindAll(t *testing.T) {
 		{Name: "User 3", Age: 35, CreatedAt: time.Now()},
 	}
 
-	_, err := repo.SaveAll(items)
+	_, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Faield to save items: %v", err)
 	}
 
-	foundItems, err := repo.FindAll()
+	foundItems, err := repo.FindAll(context.TODO())
 	if err != nil {
 		t.Fatalf("Failed to find all items: %v", err)
 	}
@@ -153,11 +243,27 @@ func TestFindAll(t *testing.T) {
 	}
 }
 
+func TestFindAllCancelled(t *testing.T) {
+	repo := setupTestRepo(t)
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Cancelled", Age: 25, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err := repo.FindAll(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected FindAll to fail with context.Canceled, got %v", err)
+	}
+	if _, err := repo.Save(ctx, TestModel{Name: "Not Saved", Age: 25, CreatedAt: time.Now()}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected Save to fail with context.Canceled, got %v", err)
+	}
+}
+
 func TestExistsById(t *testing.T) {
 	repo := setupTestRepo(t)
 
 	newItem := TestModel{Name: "John Doe", Age: 30, CreatedAt: time.Now()}
-	savedItem, err := repo.Save(newItem)
+	savedItem, err := repo.Save(context.TODO(), newItem)
 	if err != nil {
 		t.Fatalf("Failed to save new item: %v", err)
 	}
@@ -165,7 +271,7 @@ func TestExistsById(t *testing.T) {
 		t.Fatalf("Expected non-zero ID for saved item")
 	}
 
-	exists, err := repo.ExistsById(savedItem.ID)
+	exists, err := repo.ExistsById(context.TODO(), savedItem.ID)
 
 	if err != nil {
 		t.Fatalf("Failed to check if item exists: %v", err)
@@ -185,7 +291,7 @@ func TestSaveAll(t *testing.T) {
 	}
 
 	// Test saving multiple items
-	savedItems, err := repo.SaveAll(items)
+	savedItems, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Failed to save multiple items: %v", err)
 	}
@@ -202,7 +308,7 @@ func TestSaveAll(t *testing.T) {
 func TestQueryOne(t *testing.T) {
 	repo := setupTestRepo(t)
 	newItem := TestModel{Name: "Query Test", Age: 40, CreatedAt: time.Now()}
-	_, err := repo.Save(newItem)
+	_, err := repo.Save(context.TODO(), newItem)
 	if err != nil {
 		t.Fatalf("Failed to save test item: %v", err)
 	}
@@ -220,6 +326,60 @@ func TestQueryOne(t *testing.T) {
 	}
 }
 
+func TestQueryOneSortAndSkip(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Sorted One 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Sorted One 2", Age: 40, CreatedAt: time.Now()},
+		{Name: "Sorted One 3", Age: 30, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	oldest, err := repo.QueryRunner().SortDesc("age").QueryOne()
+	if err != nil {
+		t.Fatalf("Failed to query one item: %v", err)
+	}
+	if oldest.Name != "Sorted One 2" {
+		t.Fatalf("Expected the oldest item 'Sorted One 2', got '%s'", oldest.Name)
+	}
+
+	// the second page of single items skips the oldest
+	second, err := repo.QueryRunner().SortDesc("age").Pageable([2]int{1, 1}).QueryOne()
+	if err != nil {
+		t.Fatalf("Failed to query second item: %v", err)
+	}
+	if second.Name != "Sorted One 3" {
+		t.Fatalf("Expected the second oldest item 'Sorted One 3', got '%s'", second.Name)
+	}
+}
+
+func TestQueryOneProjection(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Projected 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Projected 2", Age: 30, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	youngest, err := repo.QueryRunner().
+		ProjectFields("name").
+		SortAsc("age").
+		QueryOne()
+	if err != nil {
+		t.Fatalf("Failed to query one item: %v", err)
+	}
+	if youngest.Name != "Projected 1" {
+		t.Fatalf("Expected the youngest item 'Projected 1', got '%s'", youngest.Name)
+	}
+	if youngest.Age != 0 || !youngest.CreatedAt.IsZero() {
+		t.Fatalf("Expected only the name to be projected, got %+v", youngest)
+	}
+}
+
 func TestQueryMany(t *testing.T) {
 	repo := setupTestRepo(t)
 
@@ -230,7 +390,7 @@ func TestQueryMany(t *testing.T) {
 		{Name: "Query Many 4", Age: 40, CreatedAt: time.Now()},
 	}
 
-	_, err := repo.SaveAll(items)
+	_, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Failed to save multiple items: %v", err)
 	}
@@ -267,7 +427,7 @@ func TestQueryManyB(t *testing.T) {
 		{Name: "Query Many 4", Age: 40, CreatedAt: time.Now()},
 	}
 
-	_, err := repo.SaveAll(items)
+	_, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Failed to save multiple items: %v", err)
 	}
@@ -310,7 +470,7 @@ func TestCount(t *testing.T) {
 		{Name: "Query Many 3", Age: 35, CreatedAt: time.Now()},
 	}
 
-	_, err := repo.SaveAll(items)
+	_, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Failed to save multiple items: %v", err)
 	}
@@ -336,7 +496,7 @@ func TestDelete(t *testing.T) {
 		{Name: "Query Many 3", Age: 35, CreatedAt: time.Now()},
 	}
 
-	_, err := repo.SaveAll(items)
+	_, err := repo.SaveAll(context.TODO(), items)
 	if err != nil {
 		t.Fatalf("Failed to save multiple items: %v", err)
 	}
@@ -352,7 +512,7 @@ func TestDelete(t *testing.T) {
 		t.Fatalf("Expected to find 2 items, but found %d", count)
 	}
 
-	remCount, err := repo.CountAll()
+	remCount, err := repo.CountAll(context.TODO())
 	if remCount != 1 {
 		t.Fatalf("Expected to find 1 item remaining in repo, but found %d", remCount)
 	}
@@ -368,7 +528,7 @@ func TestAggregateOne(t *testing.T) {
 		{Name: "Agg One 3", Age: 35, CreatedAt: time.Now()},
 	}
 	for _, item := range items {
-		_, err := repo.Save(item)
+		_, err := repo.Save(context.TODO(), item)
 		if err != nil {
 			t.Fatalf("Failed to save test item: %v", err)
 		}
@@ -387,6 +547,21 @@ func TestAggregateOne(t *testing.T) {
 	}
 }
 
+func TestAggregateOneEmpty(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"name": "Nobody"}},
+	}
+	result, err := repo.AggregateOne(context.TODO(), pipeline)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for a pipeline without results, got %v", err)
+	}
+	if result != nil {
+		t.Fatalf("Expected no result, got %v", result)
+	}
+}
+
 func TestAggregateMultiple(t *testing.T) {
 	repo := setupTestRepo(t)
 	ctx := context.TODO()
@@ -396,7 +571,7 @@ func TestAggregateMultiple(t *testing.T) {
 		{Name: "Agg Multi 3", Age: 35, CreatedAt: time.Now()},
 	}
 	for _, item := range items {
-		_, err := repo.Save(item)
+		_, err := repo.Save(context.TODO(), item)
 		if err != nil {
 			t.Fatalf("Failed to save test item: %v", err)
 		}
@@ -424,18 +599,2059 @@ func TestDeleteById(t *testing.T) {
 	repo := setupTestRepo(t)
 
 	newItem := TestModel{Name: "Delete Test", Age: 50, CreatedAt: time.Now()}
-	savedItem, err := repo.Save(newItem)
+	savedItem, err := repo.Save(context.TODO(), newItem)
 	if err != nil {
 		t.Fatalf("Failed to save test item: %v", err)
 	}
 
-	err = repo.DeleteById(savedItem.ID)
+	err = repo.DeleteById(context.TODO(), savedItem.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete item by ID: %v", err)
 	}
 
-	_, err = repo.FindById(savedItem.ID)
+	_, err = repo.FindById(context.TODO(), savedItem.ID)
 	if err == nil {
 		t.Fatalf("Expected item to be deleted, but it still exists")
 	}
 }
+
+func TestProjectFields(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	newItem := TestModel{Name: "Projected", Age: 33, CreatedAt: time.Now()}
+	_, err := repo.Save(context.TODO(), newItem)
+	if err != nil {
+		t.Fatalf("Failed to save test item: %v", err)
+	}
+
+	foundItems, err := repo.QueryRunner().
+		Filter(`{"name":?1}`, newItem.Name).
+		ProjectFields("name").
+		QueryMany()
+
+	if err != nil {
+		t.Fatalf("Failed to query projected items: %v", err)
+	}
+	if len(foundItems) != 1 {
+		t.Fatalf("Expected to find 1 item, but found %d", len(foundItems))
+	}
+	if foundItems[0].Name != "Projected" {
+		t.Fatalf("Expected name to be 'Projected', got '%s'", foundItems[0].Name)
+	}
+	if foundItems[0].Age != 0 || !foundItems[0].CreatedAt.IsZero() {
+		t.Fatalf("Expected non projected fields to be zero, got %+v", foundItems[0])
+	}
+}
+
+func TestProjectFieldsMixedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected mixing inclusion and exclusion to panic")
+		}
+	}()
+
+	query := &QueryBuilder[TestModel]{}
+	query.ProjectFields("name").ExcludeFields("age")
+}
+
+func TestDeleteInBatches(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	items := make([]TestModel, 250)
+	for i := range items {
+		items[i] = TestModel{Name: fmt.Sprintf("Batch %d", i), Age: i % 2, CreatedAt: time.Now()}
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	deleted, err := repo.DeleteInBatches(context.TODO(), bson.M{"age": 0}, 10)
+	if err != nil {
+		t.Fatalf("Failed to delete in batches: %v", err)
+	}
+	if deleted != 125 {
+		t.Fatalf("Expected to delete 125 items, but deleted %d", deleted)
+	}
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count remaining items: %v", err)
+	}
+	if count != 125 {
+		t.Fatalf("Expected 125 items to remain, got %d", count)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err := repo.DeleteInBatches(ctx, bson.M{}, 10); err == nil {
+		t.Fatalf("Expected a cancelled context to stop the delete")
+	}
+}
+
+func TestEachPage(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := make([]TestModel, 1000)
+	for i := range items {
+		items[i] = TestModel{Name: fmt.Sprintf("Page %d", i), Age: i % 3, CreatedAt: time.Now()}
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	visited := map[primitive.ObjectID]int{}
+	pages := 0
+	err := repo.EachPage(context.TODO(), nil, 100, func(page []TestModel) error {
+		pages++
+		for _, item := range page {
+			visited[item.ID]++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to iterate pages: %v", err)
+	}
+	if pages != 10 || len(visited) != 1000 {
+		t.Fatalf("Expected 1000 items over 10 pages, got %d items over %d pages", len(visited), pages)
+	}
+	for id, count := range visited {
+		if count != 1 {
+			t.Fatalf("Expected item %s to be visited once, got %d", id.Hex(), count)
+		}
+	}
+
+	var filtered int
+	stop := errors.New("stop")
+	err = repo.EachPage(context.TODO(), bson.M{"age": 0}, 100, func(page []TestModel) error {
+		filtered += len(page)
+		if filtered >= 200 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || filtered != 200 {
+		t.Fatalf("Expected iteration to stop after 200 items with the error of fn, got %d, %v", filtered, err)
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.TODO()
+
+	items := []TestModel{
+		{Name: "Delete All 1", Age: 21, CreatedAt: time.Now()},
+		{Name: "Delete All 2", Age: 22, CreatedAt: time.Now()},
+		{Name: "Delete All 3", Age: 23, CreatedAt: time.Now()},
+		{Name: "Delete All 4", Age: 24, CreatedAt: time.Now()},
+		{Name: "Delete All 5", Age: 25, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	deleted, err := repo.DeleteAll(ctx)
+	if err != nil {
+		t.Fatalf("Failed to delete all items: %v", err)
+	}
+	if deleted != 5 {
+		t.Fatalf("Expected to delete 5 items, but deleted %d", deleted)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items after deleting: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected db count to be 0 got %d", count)
+	}
+
+	cursor, err := repo.collection.Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+	var indexes []bson.M
+	if err = cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("Failed to decode indexes: %v", err)
+	}
+	if len(indexes) <= 1 {
+		t.Fatalf("Expected declared indexes to remain, but found %d", len(indexes))
+	}
+}
+
+func TestEstimatedCount(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "User 1", Age: 25, CreatedAt: time.Now()},
+		{Name: "User 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "User 3", Age: 35, CreatedAt: time.Now()},
+	}
+
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	count, err := repo.EstimatedCount(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to estimate count: %v", err)
+	}
+	if count < 1 || count > 3 {
+		t.Fatalf("Expected estimated count between 1 and 3, but got %d", count)
+	}
+}
+
+func TestInsertManyUnordered(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	items := []TestModel{
+		{Name: "Insert 1", Age: 25, CreatedAt: time.Now()},
+		{Name: "Insert 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "Insert 1", Age: 35, CreatedAt: time.Now()},
+		{Name: "Insert 3", Age: 40, CreatedAt: time.Now()},
+	}
+
+	inserted, err := repo.InsertMany(context.TODO(), items, false)
+	if err == nil {
+		t.Fatalf("Expected duplicate key error from insert")
+	}
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("Expected error to wrap a BulkWriteException, got %v", err)
+	}
+	if len(inserted) != 3 {
+		t.Fatalf("Expected 3 inserted items, but got %d", len(inserted))
+	}
+	for _, item := range inserted {
+		if item.ID.IsZero() {
+			t.Fatalf("Expected non-zero ID for inserted item")
+		}
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items after inserting: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected db count to be 3 got %d", count)
+	}
+}
+
+func TestInsertManyOrdered(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	items := []TestModel{
+		{Name: "Insert 1", Age: 25, CreatedAt: time.Now()},
+		{Name: "Insert 1", Age: 30, CreatedAt: time.Now()},
+		{Name: "Insert 2", Age: 35, CreatedAt: time.Now()},
+	}
+
+	inserted, err := repo.InsertMany(context.TODO(), items, true)
+	if err == nil {
+		t.Fatalf("Expected duplicate key error from insert")
+	}
+	if len(inserted) != 1 || inserted[0].Name != "Insert 1" {
+		t.Fatalf("Expected only the first item to be inserted, got %+v", inserted)
+	}
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+	repo, err := NewMongoRepository[AccountModel](setupTestCollection(t, "accounts"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	_, err = repo.Save(context.TODO(), AccountModel{Email: "duplicate@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to save first account: %v", err)
+	}
+
+	_, err = repo.Save(context.TODO(), AccountModel{Email: "duplicate@example.com"})
+	if !IsDuplicateKeyError(err) {
+		t.Fatalf("Expected duplicate key error, got %v", err)
+	}
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Expected error to wrap ErrDuplicateKey, got %v", err)
+	}
+}
+
+type StringKeyModel struct {
+	ID   string `bson:"_id"`
+	Name string `bson:"name"`
+}
+
+type TenantKey struct {
+	Tenant string `bson:"tenant"`
+	Key    string `bson:"key"`
+}
+
+type CompositeKeyModel struct {
+	ID    TenantKey `bson:"_id"`
+	Value int       `bson:"value"`
+}
+
+func TestStringKeyModel(t *testing.T) {
+	repo, err := NewMongoRepository[StringKeyModel](setupTestCollection(t, "stringkeys"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	_, err = repo.Save(context.TODO(), StringKeyModel{Name: "No Key"})
+	if !errors.Is(err, ErrMissingId) {
+		t.Fatalf("Expected missing id error for unset string id, got %v", err)
+	}
+
+	savedItem, err := repo.Save(context.TODO(), StringKeyModel{ID: "user-1", Name: "String Key"})
+	if err != nil {
+		t.Fatalf("Failed to save item with string id: %v", err)
+	}
+
+	foundItem, err := repo.FindById(context.TODO(), "user-1")
+	if err != nil {
+		t.Fatalf("Failed to find item by string id: %v", err)
+	}
+	if foundItem != savedItem {
+		t.Fatalf("Expected found item %+v to match saved item %+v", foundItem, savedItem)
+	}
+
+	exists, err := repo.ExistsById(context.TODO(), "user-1")
+	if err != nil || !exists {
+		t.Fatalf("Expected item with string id to exist, got %v %v", exists, err)
+	}
+
+	err = repo.DeleteById(context.TODO(), "user-1")
+	if err != nil {
+		t.Fatalf("Failed to delete item by string id: %v", err)
+	}
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected db count to be 0 got %d", count)
+	}
+}
+
+func TestFindByIdsOrdered(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "User 1", Age: 25, CreatedAt: time.Now()},
+		{Name: "User 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "User 3", Age: 35, CreatedAt: time.Now()},
+	}
+
+	savedItems, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	ids := []primitive.ObjectID{savedItems[2].ID, primitive.NewObjectID(), savedItems[0].ID, savedItems[1].ID}
+
+	foundItems, err := repo.FindByIdsOrdered(context.TODO(), ids)
+	if err != nil {
+		t.Fatalf("Failed to find items by ids: %v", err)
+	}
+	if len(foundItems) != 3 {
+		t.Fatalf("Expected to find 3 items, but found %d", len(foundItems))
+	}
+	if foundItems[0].Name != "User 3" || foundItems[1].Name != "User 1" || foundItems[2].Name != "User 2" {
+		t.Fatalf("Expected items in requested order, got %+v", foundItems)
+	}
+}
+
+func TestEmptyInputs(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	foundItems, err := repo.FindByIds(context.TODO(), []primitive.ObjectID{})
+	if err != nil {
+		t.Fatalf("Expected no error finding empty ids, got %v", err)
+	}
+	if len(foundItems) != 0 {
+		t.Fatalf("Expected no items for empty ids, but found %d", len(foundItems))
+	}
+
+	savedItems, err := repo.SaveAll(context.TODO(), []TestModel{})
+	if err != nil {
+		t.Fatalf("Expected no error saving empty items, got %v", err)
+	}
+	if len(savedItems) != 0 {
+		t.Fatalf("Expected no saved items, but got %d", len(savedItems))
+	}
+
+	insertedItems, err := repo.InsertMany(context.TODO(), nil, true)
+	if err != nil {
+		t.Fatalf("Expected no error inserting empty items, got %v", err)
+	}
+	if len(insertedItems) != 0 {
+		t.Fatalf("Expected no inserted items, but got %d", len(insertedItems))
+	}
+}
+
+func TestQueryManyBatchSize(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	var items []TestModel
+	for i := 0; i < 25; i++ {
+		items = append(items, TestModel{Name: fmt.Sprintf("Batch %d", i), Age: i, CreatedAt: time.Now()})
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	foundItems, err := repo.QueryRunner().
+		FilterB(bson.M{}).
+		BatchSize(4).
+		QueryMany()
+
+	if err != nil {
+		t.Fatalf("Failed to query items in batches: %v", err)
+	}
+	if len(foundItems) != len(items) {
+		t.Fatalf("Expected to find %d items, but found %d", len(items), len(foundItems))
+	}
+}
+
+func TestReadPreference(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	items := []TestModel{
+		{Name: "Read Pref 1", Age: 25, CreatedAt: time.Now()},
+		{Name: "Read Pref 2", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	foundItems, err := repo.QueryRunner().
+		FilterB(bson.M{"age": bson.M{"$gte": 25}}).
+		ReadPreference(readpref.SecondaryPreferred()).
+		ReadConcern(readconcern.Local()).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query with read preference: %v", err)
+	}
+	if len(foundItems) != 2 {
+		t.Fatalf("Expected to find 2 items, but found %d", len(foundItems))
+	}
+
+	secondaryRepo := repo.WithReadPreference(readpref.SecondaryPreferred())
+	count, err := secondaryRepo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count with repository read preference: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected db count to be 2 got %d", count)
+	}
+}
+
+func TestWithMaxStaleness(t *testing.T) {
+	rp, err := withMaxStaleness(nil, 2*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to bound staleness: %v", err)
+	}
+	if maxStaleness, _ := rp.MaxStaleness(); rp.Mode() != readpref.SecondaryPreferredMode || maxStaleness != 2*time.Minute {
+		t.Fatalf("Expected a secondary preferred read bounded to 2m, got %v", rp)
+	}
+
+	rp, err = withMaxStaleness(readpref.Nearest(), 2*time.Minute)
+	if err != nil || rp.Mode() != readpref.NearestMode {
+		t.Fatalf("Expected the nearest mode to be kept, got %v, %v", rp, err)
+	}
+
+	if _, err := withMaxStaleness(nil, 30*time.Second); err == nil || !strings.Contains(err.Error(), "minimum") {
+		t.Fatalf("Expected a staleness below 90s to be rejected, got %v", err)
+	}
+	if _, err := withMaxStaleness(readpref.Primary(), 2*time.Minute); err == nil {
+		t.Fatalf("Expected a staleness bound on primary reads to be rejected")
+	}
+}
+
+func TestMaxStaleness(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	var hello bson.M
+	err := repo.Database().RunCommand(context.TODO(), bson.M{"hello": 1}).Decode(&hello)
+	if err != nil {
+		t.Fatalf("Failed to run hello command: %v", err)
+	}
+	if _, ok := hello["setName"]; !ok {
+		t.Skip("max staleness requires a replica set")
+	}
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Stale", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	_, err = repo.QueryRunner().MaxStaleness(2 * time.Minute).QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query with max staleness: %v", err)
+	}
+	_, err = repo.QueryRunner().MaxStaleness(time.Second).QueryMany()
+	if err == nil {
+		t.Fatalf("Expected a staleness below 90s to fail the query")
+	}
+}
+
+func TestWriteConcern(t *testing.T) {
+	repo := setupTestRepo(t).WithWriteConcern(writeconcern.Majority())
+
+	savedItem, err := repo.Save(context.TODO(), TestModel{Name: "Majority", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save with majority write concern: %v", err)
+	}
+
+	foundItem, err := repo.FindById(context.TODO(), savedItem.ID)
+	if err != nil {
+		t.Fatalf("Failed to find item saved with majority write concern: %v", err)
+	}
+	if foundItem.Name != "Majority" {
+		t.Fatalf("Expected found name to be 'Majority', got '%s'", foundItem.Name)
+	}
+}
+
+type Address struct {
+	Street string `bson:"street"`
+	City   string `bson:"city" index:"1"`
+}
+
+type NestedIndexModel struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Name    string             `bson:"name"`
+	Address Address            `bson:"address"`
+}
+
+func listIndexKeys(t *testing.T, collection *mongo.Collection) []bson.D {
+	cursor, err := collection.Indexes().List(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+	var indexes []struct {
+		Key bson.D `bson:"key"`
+	}
+	if err = cursor.All(context.TODO(), &indexes); err != nil {
+		t.Fatalf("Failed to decode indexes: %v", err)
+	}
+	var keys []bson.D
+	for _, index := range indexes {
+		keys = append(keys, index.Key)
+	}
+	return keys
+}
+
+func TestNestedIndex(t *testing.T) {
+	collection := setupTestCollection(t, "nestedindexes")
+	_, err := NewMongoRepository[NestedIndexModel](collection)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	for _, key := range listIndexKeys(t, collection) {
+		if len(key) == 1 && key[0].Key == "address.city" {
+			return
+		}
+	}
+	t.Fatalf("Expected an index on address.city")
+}
+
+type WildcardIndexModel struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	Metadata map[string]string  `bson:"metadata" index:"wildcard"`
+}
+
+func TestWildcardIndex(t *testing.T) {
+	collection := setupTestCollection(t, "wildcardindexes")
+	repo, err := NewMongoRepository[WildcardIndexModel](collection)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), WildcardIndexModel{Metadata: map[string]string{"color": "red"}}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	plan, err := repo.QueryRunner().
+		Filter(`{"metadata.color": ?1}`, "red").
+		Explain(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	winningPlan := fmt.Sprint(plan["queryPlanner"].(bson.M)["winningPlan"])
+	if !strings.Contains(winningPlan, "metadata.$**") {
+		t.Fatalf("Expected query on metadata.color to use the wildcard index, got %s", winningPlan)
+	}
+}
+
+func TestWildcardIndexTag(t *testing.T) {
+	index, err := parseIndexTag("metadata", "$**")
+	if err != nil {
+		t.Fatalf("Expected wildcard index tag to parse, got %v", err)
+	}
+	if keys := index.Keys.(bson.D); keys[0].Key != "metadata.$**" || keys[0].Value != 1 {
+		t.Fatalf("Expected index on metadata.$**, got %v", keys)
+	}
+
+	if _, err := parseIndexTag("metadata", "wildcard, unique"); err == nil {
+		t.Fatalf("Expected unique wildcard index to be rejected")
+	}
+
+	type invalidModel struct {
+		Name string `bson:"name" index:"wildcard"`
+	}
+	if _, err := collectIndexes(reflect.TypeOf(invalidModel{}), "", map[reflect.Type]bool{}); err == nil {
+		t.Fatalf("Expected wildcard index on a string field to be rejected")
+	}
+}
+
+type HashedIndexModel struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Owner string             `bson:"owner" index:"hashed"`
+}
+
+func TestHashedIndex(t *testing.T) {
+	collection := setupTestCollection(t, "hashedindexes")
+	_, err := NewMongoRepository[HashedIndexModel](collection)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	for _, key := range listIndexKeys(t, collection) {
+		if len(key) == 1 && key[0].Key == "owner" && key[0].Value == "hashed" {
+			return
+		}
+	}
+	t.Fatalf("Expected a hashed index on owner")
+}
+
+type TicketModel struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" cindex:"{status:1, description:text}"`
+	Status      string             `bson:"status"`
+	Description string             `bson:"description"`
+}
+
+func TestCompoundTextIndex(t *testing.T) {
+	collection := setupTestCollection(t, "tickets")
+	if _, err := NewMongoRepository[TicketModel](collection); err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	// the server lists text keys as _fts & _ftsx
+	for _, key := range listIndexKeys(t, collection) {
+		if len(key) == 3 && key[0].Key == "status" && key[1].Key == "_fts" && key[1].Value == "text" {
+			return
+		}
+	}
+	t.Fatalf("Expected a compound index on status & description text")
+}
+
+type NaturalKeyModel struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" cindex:"{name:1,age:1,unique:true,name:natural_key}"`
+	Name string             `bson:"name"`
+	Age  int                `bson:"age"`
+}
+
+func TestUniqueCompoundIndex(t *testing.T) {
+	repo, err := NewMongoRepository[NaturalKeyModel](setupTestCollection(t, "natural_keys"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	if _, err := repo.Save(context.TODO(), NaturalKeyModel{Name: "Natural", Age: 30}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), NaturalKeyModel{Name: "Natural", Age: 31}); err != nil {
+		t.Fatalf("Failed to save item with another age: %v", err)
+	}
+	_, err = repo.Save(context.TODO(), NaturalKeyModel{Name: "Natural", Age: 30})
+	if !IsDuplicateKeyError(err) {
+		t.Fatalf("Expected duplicate (name, age) to be rejected, got %v", err)
+	}
+}
+
+func TestParseCompoundIndexTagOptions(t *testing.T) {
+	indexes, err := parseCompoundIndexTag("{name:1,age:1,unique:true,sparse:true,name:natural_key}")
+	if err != nil {
+		t.Fatalf("Expected compound index tag to parse, got %v", err)
+	}
+	index := indexes[0]
+	expectedKeys := bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}}
+	if !reflect.DeepEqual(index.Keys, expectedKeys) {
+		t.Fatalf("Expected index keys %v, got %v", expectedKeys, index.Keys)
+	}
+	if !*index.Options.Unique || !*index.Options.Sparse || *index.Options.Name != "natural_key" {
+		t.Fatalf("Expected a sparse unique index named natural_key, got %+v", index.Options)
+	}
+
+	invalidTags := []string{"{name:1,unique:yes}", "{owner:hashed,unique:true}"}
+	for _, tag := range invalidTags {
+		if _, err := parseCompoundIndexTag(tag); err == nil {
+			t.Fatalf("Expected compound index tag %q to be rejected", tag)
+		}
+	}
+}
+
+func TestParseCompoundIndexTag(t *testing.T) {
+	indexes, err := parseCompoundIndexTag("{status:1, location:2dsphere};{owner:hashed,created_at:-1}")
+	if err != nil {
+		t.Fatalf("Expected compound index tag to parse, got %v", err)
+	}
+	expected := []bson.D{
+		{{Key: "status", Value: 1}, {Key: "location", Value: "2dsphere"}},
+		{{Key: "owner", Value: "hashed"}, {Key: "created_at", Value: -1}},
+	}
+	if len(indexes) != len(expected) {
+		t.Fatalf("Expected %d indexes, got %d", len(expected), len(indexes))
+	}
+	for i, index := range indexes {
+		if !reflect.DeepEqual(index.Keys, expected[i]) {
+			t.Fatalf("Expected index keys %v, got %v", expected[i], index.Keys)
+		}
+	}
+
+	invalidTags := []string{"{name:up}", "{a:text,b:text}", "{a:hashed,b:hashed}", "{a:text,b:2dsphere}", "{name}"}
+	for _, tag := range invalidTags {
+		if _, err := parseCompoundIndexTag(tag); err == nil {
+			t.Fatalf("Expected compound index tag %q to be rejected", tag)
+		}
+	}
+}
+
+func TestListIndexes(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.TODO()
+	if _, err := repo.EnsureIndex(ctx, bson.D{{Key: "created_at", Value: -1}}, options.Index().SetExpireAfterSeconds(3600)); err != nil {
+		t.Fatalf("Failed to create TTL index: %v", err)
+	}
+
+	indexes, err := repo.ListIndexes(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+	byName := map[string]IndexInfo{}
+	for _, index := range indexes {
+		byName[index.Name] = index
+	}
+	if _, ok := byName["_id_"]; !ok {
+		t.Fatalf("Expected the _id_ index to be listed, got %+v", indexes)
+	}
+	if name, ok := byName["name_1"]; !ok || !name.Unique || name.Keys[0].Key != "name" {
+		t.Fatalf("Expected the unique name index declared by tags to be listed, got %+v", name)
+	}
+	if ttl, ok := byName["created_at_-1"]; !ok || ttl.ExpireAfterSeconds == nil || *ttl.ExpireAfterSeconds != 3600 {
+		t.Fatalf("Expected the TTL index to be listed with its expiry, got %+v", ttl)
+	}
+}
+
+func TestDropAndRecreateIndexes(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.TODO()
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Dropped", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	if err := repo.WithScope(bson.M{"age": 30}).Drop(ctx); err == nil {
+		t.Fatalf("Expected scoped repository to refuse dropping the collection")
+	}
+
+	if err := repo.DropAndRecreateIndexes(ctx); err != nil {
+		t.Fatalf("Failed to drop and recreate indexes: %v", err)
+	}
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected dropped collection to be empty, got %d items", count)
+	}
+
+	indexed := map[string]bool{}
+	for _, key := range listIndexKeys(t, repo.Collection()) {
+		indexed[fmt.Sprint(key)] = true
+	}
+	for _, key := range []bson.D{{{Key: "name", Value: 1}}, {{Key: "name", Value: 1}, {Key: "age", Value: 1}}} {
+		if !indexed[fmt.Sprint(key)] {
+			t.Fatalf("Expected index %v to be recreated, got %v", key, indexed)
+		}
+	}
+}
+
+func TestParseIndexTagConflicts(t *testing.T) {
+	invalidTags := []string{"1, -1", "text, 2dsphere", "-1, text", "1, 1", "unique, ascending", "hashed, unique", "hashed, 1"}
+	for _, tag := range invalidTags {
+		_, err := parseIndexTag("age", tag)
+		if err == nil {
+			t.Fatalf("Expected index tag %q to be rejected", tag)
+		}
+		if !strings.Contains(err.Error(), "age") {
+			t.Fatalf("Expected error for index tag %q to name the field, got %v", tag, err)
+		}
+	}
+
+	index, err := parseIndexTag("age", "-1, unique, sparse")
+	if err != nil {
+		t.Fatalf("Expected valid index tag to parse, got %v", err)
+	}
+	if index.Keys.(bson.D)[0].Value != -1 {
+		t.Fatalf("Expected descending index, got %v", index.Keys)
+	}
+}
+
+type HandleModel struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Handle string             `bson:"handle,omitempty" index:"1, unique, sparse"`
+}
+
+func TestUniqueSparseIndex(t *testing.T) {
+	repo, err := NewMongoRepository[HandleModel](setupTestCollection(t, "handles"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := repo.Save(context.TODO(), HandleModel{}); err != nil {
+			t.Fatalf("Expected documents without a handle not to conflict, got %v", err)
+		}
+	}
+	if _, err := repo.Save(context.TODO(), HandleModel{Handle: "taken"}); err != nil {
+		t.Fatalf("Failed to save handle: %v", err)
+	}
+	_, err = repo.Save(context.TODO(), HandleModel{Handle: "taken"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Expected duplicate handles to be rejected, got %v", err)
+	}
+}
+
+func TestUniqueSparseIndexTag(t *testing.T) {
+	for _, tag := range []string{"1, unique, sparse", "sparse, unique, 1", "unique,sparse"} {
+		index, err := parseIndexTag("handle", tag)
+		if err != nil {
+			t.Fatalf("Expected index tag %q to parse, got %v", tag, err)
+		}
+		if index.Options.Unique == nil || !*index.Options.Unique || index.Options.Sparse == nil || !*index.Options.Sparse {
+			t.Fatalf("Expected index tag %q to be unique & sparse, got %+v", tag, index.Options)
+		}
+	}
+
+	type invalidModel struct {
+		Handle string `bson:"handle" index:"1, unique, sparse"`
+	}
+	if _, err := collectIndexes(reflect.TypeOf(invalidModel{}), "", map[reflect.Type]bool{}); err == nil {
+		t.Fatalf("Expected unique sparse index on a field without omitempty to be rejected")
+	}
+}
+
+func TestQueryManySortAscDesc(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	items := []TestModel{
+		{Name: "B", Age: 30, CreatedAt: time.Now()},
+		{Name: "A", Age: 30, CreatedAt: time.Now()},
+		{Name: "C", Age: 40, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	foundItems, err := repo.QueryRunner().
+		FilterB(bson.M{}).
+		SortDesc("age").
+		SortAsc("name").
+		QueryMany()
+
+	if err != nil {
+		t.Fatalf("Failed to query many items: %v", err)
+	}
+	if len(foundItems) != 3 || foundItems[0].Name != "C" || foundItems[1].Name != "A" || foundItems[2].Name != "B" {
+		t.Fatalf("Expected items sorted by age desc then name asc, got %+v", foundItems)
+	}
+}
+
+func TestSaveWithResult(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	savedItem, result, err := repo.SaveWithResult(context.TODO(), TestModel{Name: "Result", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save new item: %v", err)
+	}
+	if !result.Inserted || result.MatchedCount != 0 || result.UpsertedID != savedItem.ID {
+		t.Fatalf("Expected insert result for new item, got %+v", result)
+	}
+
+	savedItem.Age = 31
+	_, result, err = repo.SaveWithResult(context.TODO(), savedItem)
+	if err != nil {
+		t.Fatalf("Failed to update item: %v", err)
+	}
+	if result.Inserted || result.MatchedCount != 1 || result.UpsertedID != nil {
+		t.Fatalf("Expected update result for existing item, got %+v", result)
+	}
+}
+
+func TestFindIdFieldIndexTagForms(t *testing.T) {
+	type plainId struct {
+		Name string             `bson:"name"`
+		ID   primitive.ObjectID `bson:"_id"`
+	}
+	type omitEmptyId struct {
+		Name string             `bson:"name"`
+		ID   primitive.ObjectID `bson:"_id,omitempty"`
+	}
+	type missingId struct {
+		Name string `bson:"name"`
+	}
+	type laterSegmentId struct {
+		Name string             `bson:"name"`
+		ID   primitive.ObjectID `bson:"foo,_id"`
+	}
+
+	for _, model := range []interface{}{plainId{}, omitEmptyId{}} {
+		index, err := findIdFieldIndex(reflect.TypeOf(model))
+		if err != nil {
+			t.Fatalf("Expected id field to be found on %T, got %v", model, err)
+		}
+		if index != 1 {
+			t.Fatalf("Expected id field index 1 on %T, got %d", model, index)
+		}
+	}
+
+	for _, model := range []interface{}{missingId{}, laterSegmentId{}} {
+		if _, err := findIdFieldIndex(reflect.TypeOf(model)); err == nil {
+			t.Fatalf("Expected an error for %T, which has no field named _id", model)
+		}
+	}
+}
+
+func TestQueryChan(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	var items []TestModel
+	for i := 0; i < 10; i++ {
+		items = append(items, TestModel{Name: fmt.Sprintf("Stream %d", i), Age: i, CreatedAt: time.Now()})
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	results, errs := repo.QueryRunner().
+		FilterB(bson.M{}).
+		BatchSize(3).
+		QueryChan(context.Background())
+
+	var streamed []TestModel
+	for item := range results {
+		streamed = append(streamed, item)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Failed to stream items: %v", err)
+	}
+	if len(streamed) != len(items) {
+		t.Fatalf("Expected to stream %d items, but got %d", len(items), len(streamed))
+	}
+}
+
+func TestQueryChanCancel(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	var items []TestModel
+	for i := 0; i < 10; i++ {
+		items = append(items, TestModel{Name: fmt.Sprintf("Stream %d", i), Age: i, CreatedAt: time.Now()})
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save multiple items: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errs := repo.QueryRunner().
+		FilterB(bson.M{}).
+		BatchSize(3).
+		QueryChan(ctx)
+
+	if _, ok := <-results; !ok {
+		t.Fatalf("Expected to receive an item before cancelling")
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the streaming goroutine to exit after cancellation")
+	}
+	for range results {
+	}
+}
+
+func TestUpsertByFilter(t *testing.T) {
+	collection := setupTestCollection(t, "upsert_by_filter")
+	repo, err := NewMongoRepository[AccountModel](collection)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	filter := bson.M{"email": "sync@example.com"}
+	first, inserted, err := repo.UpsertByFilter(context.TODO(), filter, AccountModel{Email: "sync@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to upsert new item: %v", err)
+	}
+	if !inserted {
+		t.Fatalf("Expected first upsert to insert")
+	}
+	if first.ID.IsZero() {
+		t.Fatalf("Expected generated ID to be populated on insert")
+	}
+
+	_, inserted, err = repo.UpsertByFilter(context.TODO(), filter, AccountModel{Email: "sync@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to upsert existing item: %v", err)
+	}
+	if inserted {
+		t.Fatalf("Expected second upsert to update rather than insert")
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 item after upserting twice, got %d", count)
+	}
+}
+
+type SyncedModel struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Key       string             `bson:"key" index:"1, unique"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func TestUpsertSetOnInsert(t *testing.T) {
+	repo, err := NewMongoRepository[SyncedModel](setupTestCollection(t, "synced"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	ctx := context.TODO()
+	filter := bson.M{"key": "upserted"}
+
+	created := time.Now().Truncate(time.Millisecond)
+	first, err := repo.Upsert(ctx, filter, bson.M{"updated_at": created}, bson.M{"created_at": created})
+	if err != nil {
+		t.Fatalf("Failed to upsert new item: %v", err)
+	}
+	if first.ID.IsZero() || first.Key != "upserted" {
+		t.Fatalf("Expected inserted item to be returned, got %+v", first)
+	}
+
+	updated := created.Add(time.Minute)
+	second, err := repo.Upsert(ctx, filter, bson.M{"updated_at": updated}, bson.M{"created_at": updated})
+	if err != nil {
+		t.Fatalf("Failed to upsert existing item: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("Expected second upsert to update the same item")
+	}
+	if !second.CreatedAt.Equal(created) {
+		t.Fatalf("Expected created_at to stay %v, got %v", created, second.CreatedAt)
+	}
+	if !second.UpdatedAt.Equal(updated) {
+		t.Fatalf("Expected updated_at to be %v, got %v", updated, second.UpdatedAt)
+	}
+}
+
+func TestUpdateByIdAndReturn(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.TODO()
+
+	item, err := repo.Save(context.TODO(), TestModel{Name: "Returned", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	updated, err := repo.UpdateByIdAndReturn(ctx, item.ID, bson.M{"name": "Returned Updated"})
+	if err != nil {
+		t.Fatalf("Failed to update item: %v", err)
+	}
+	if updated.Name != "Returned Updated" || updated.Age != 30 {
+		t.Fatalf("Expected returned item to reflect the update, got %+v", updated)
+	}
+
+	updated, err = repo.UpdateByIdAndReturn(ctx, item.ID, bson.M{"$inc": bson.M{"age": 1}})
+	if err != nil {
+		t.Fatalf("Failed to increment age: %v", err)
+	}
+	if updated.Age != 31 {
+		t.Fatalf("Expected returned age to be 31, got %d", updated.Age)
+	}
+
+	_, err = repo.UpdateByIdAndReturn(ctx, primitive.NewObjectID(), bson.M{"name": "Missing"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for unknown id, got %v", err)
+	}
+}
+
+func TestSaveFields(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.TODO()
+
+	item, err := repo.Save(context.TODO(), TestModel{Name: "Partial", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	// a concurrent writer changes the age after item was loaded
+	_, err = repo.Collection().UpdateOne(ctx, bson.M{"_id": item.ID}, bson.M{"$set": bson.M{"age": 31}})
+	if err != nil {
+		t.Fatalf("Failed to update item concurrently: %v", err)
+	}
+
+	item.Name = "Partially Saved"
+	if err := repo.SaveFields(ctx, item.ID, item, "name"); err != nil {
+		t.Fatalf("Failed to save fields: %v", err)
+	}
+
+	found, err := repo.FindById(context.TODO(), item.ID)
+	if err != nil {
+		t.Fatalf("Failed to find item: %v", err)
+	}
+	if found.Name != "Partially Saved" {
+		t.Fatalf("Expected name to be saved, got '%s'", found.Name)
+	}
+	if found.Age != 31 {
+		t.Fatalf("Expected concurrent age change to be kept, got %d", found.Age)
+	}
+
+	if err := repo.SaveFields(ctx, item.ID, item, "nickname"); err == nil {
+		t.Fatalf("Expected unknown field to be rejected")
+	}
+	if err := repo.SaveFields(ctx, primitive.NewObjectID(), item, "name"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for unknown id, got %v", err)
+	}
+}
+
+func TestForCollection(t *testing.T) {
+	tenantA := setupTestRepo(t)
+	tenantB := tenantA.ForCollection(setupTestCollection(t, "tenant_b"))
+
+	if _, err := tenantA.Save(context.TODO(), TestModel{Name: "Tenant A", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item for tenant A: %v", err)
+	}
+	if _, err := tenantB.Save(context.TODO(), TestModel{Name: "Tenant B", Age: 40, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item for tenant B: %v", err)
+	}
+
+	itemsA, err := tenantA.FindAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to find items for tenant A: %v", err)
+	}
+	itemsB, err := tenantB.FindAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to find items for tenant B: %v", err)
+	}
+	if len(itemsA) != 1 || itemsA[0].Name != "Tenant A" {
+		t.Fatalf("Expected only tenant A's item in its collection, got %v", itemsA)
+	}
+	if len(itemsB) != 1 || itemsB[0].Name != "Tenant B" {
+		t.Fatalf("Expected only tenant B's item in its collection, got %v", itemsB)
+	}
+}
+
+func TestQueryAlternateCollection(t *testing.T) {
+	repo := setupTestRepo(t)
+	archive := repo.ForCollection(setupTestCollection(t, "testcollection_archive"))
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Current", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save current item: %v", err)
+	}
+	if _, err := archive.Save(context.TODO(), TestModel{Name: "Archived", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save archived item: %v", err)
+	}
+
+	foundItems, err := repo.QueryRunner().
+		Collection("testcollection_archive").
+		Filter(`{"age": ?1}`, 30).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query alternate collection: %v", err)
+	}
+	if len(foundItems) != 1 || foundItems[0].Name != "Archived" {
+		t.Fatalf("Expected only the archived item, got %v", foundItems)
+	}
+}
+
+func TestCollectionAccessors(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if name := repo.Collection().Name(); name != "testcollection" {
+		t.Fatalf("Expected collection name 'testcollection', got '%s'", name)
+	}
+	if name := repo.Database().Name(); name != "testdb" {
+		t.Fatalf("Expected database name 'testdb', got '%s'", name)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Explained", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	plan, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		Sort(`{"name":1}`).
+		Explain(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	if _, ok := plan["queryPlanner"]; !ok {
+		t.Fatalf("Expected plan to contain 'queryPlanner', got %v", plan)
+	}
+}
+
+func TestQueryIds(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Ids 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Ids 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "Ids 3", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	ids, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		Sort(`{"name":1}`).
+		QueryIds()
+	if err != nil {
+		t.Fatalf("Failed to query ids: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != items[1].ID || ids[1] != items[2].ID {
+		t.Fatalf("Expected ids %v, got %v", []primitive.ObjectID{items[1].ID, items[2].ID}, ids)
+	}
+}
+
+func TestCompositeKeyModel(t *testing.T) {
+	repo, err := NewMongoRepository[CompositeKeyModel](setupTestCollection(t, "compositekeys"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	_, err = repo.Save(context.TODO(), CompositeKeyModel{Value: 1})
+	if !errors.Is(err, ErrMissingId) {
+		t.Fatalf("Expected missing id error for unset composite id, got %v", err)
+	}
+
+	key := TenantKey{Tenant: "acme", Key: "settings"}
+	if _, err := repo.Save(context.TODO(), CompositeKeyModel{ID: key, Value: 1}); err != nil {
+		t.Fatalf("Failed to save item with composite id: %v", err)
+	}
+	_, err = repo.SaveAll(context.TODO(), []CompositeKeyModel{
+		{ID: key, Value: 2},
+		{ID: TenantKey{Tenant: "globex", Key: "settings"}, Value: 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save items with composite ids: %v", err)
+	}
+
+	foundItem, err := repo.FindById(context.TODO(), key)
+	if err != nil {
+		t.Fatalf("Failed to find item by composite id: %v", err)
+	}
+	if foundItem.ID != key || foundItem.Value != 2 {
+		t.Fatalf("Expected upserted item with value 2, got %+v", foundItem)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected db count to be 2 got %d", count)
+	}
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Dry 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Dry 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "Dry 3", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	deletedCount, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		DryRun().
+		Delete()
+	if !errors.Is(err, ErrDryRun) {
+		t.Fatalf("Expected dry run error, got %v", err)
+	}
+	if deletedCount != 2 {
+		t.Fatalf("Expected dry run to report 2 deletions, got %d", deletedCount)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected no items to be deleted, got count %d", count)
+	}
+}
+
+type NameAge struct {
+	Name string `bson:"name"`
+	Age  int    `bson:"age"`
+}
+
+func TestQueryManyInto(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Dto 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Dto 2", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	results, err := QueryManyInto[NameAge](repo.QueryRunner().
+		FilterB(bson.M{}).
+		ProjectFields("name", "age").
+		Sort(`{"age":1}`))
+	if err != nil {
+		t.Fatalf("Failed to query into dto: %v", err)
+	}
+	expected := []NameAge{{Name: "Dto 1", Age: 20}, {Name: "Dto 2", Age: 30}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, got %v", expected, results)
+	}
+}
+
+type LabeledItem struct {
+	Name  string `bson:"name"`
+	Label string `bson:"label"`
+}
+
+func TestAddFields(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Computed 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Computed 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "Computed 3", Age: 40, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	results, err := QueryManyInto[LabeledItem](repo.QueryRunner().
+		Filter(`{"age":{"$gte": ?1}}`, 30).
+		AddFields(bson.M{"label": bson.M{"$concat": bson.A{"$name", " (", bson.M{"$toString": "$age"}, ")"}}}).
+		ProjectFields("name", "label").
+		SortDesc("age"))
+	if err != nil {
+		t.Fatalf("Failed to query computed fields: %v", err)
+	}
+	expected := []LabeledItem{{Name: "Computed 3", Label: "Computed 3 (40)"}, {Name: "Computed 2", Label: "Computed 2 (30)"}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, got %v", expected, results)
+	}
+}
+
+func TestFindAllWith(t *testing.T) {
+	repo := setupTestRepo(t)
+	now := time.Now().Truncate(time.Millisecond)
+	var items []TestModel
+	for i := 0; i < 5; i++ {
+		items = append(items, TestModel{Name: fmt.Sprintf("Recent %d", i), Age: i, CreatedAt: now.Add(time.Duration(i) * time.Minute)})
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	recent, err := repo.FindAllWith(context.TODO(), options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(3))
+	if err != nil {
+		t.Fatalf("Failed to find recent items: %v", err)
+	}
+	if len(recent) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(recent))
+	}
+	for i, item := range recent {
+		if expected := fmt.Sprintf("Recent %d", 4-i); item.Name != expected {
+			t.Fatalf("Expected item %d to be '%s', got '%s'", i, expected, item.Name)
+		}
+	}
+}
+
+func TestDefaultSort(t *testing.T) {
+	repo := setupTestRepo(t).WithDefaultSort(bson.D{{Key: "age", Value: -1}})
+	items := []TestModel{
+		{Name: "Sorted 2", Age: 20, CreatedAt: time.Now()},
+		{Name: "Sorted 3", Age: 30, CreatedAt: time.Now()},
+		{Name: "Sorted 1", Age: 10, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	found, err := repo.FindAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to find all items: %v", err)
+	}
+	for i, expected := range []string{"Sorted 3", "Sorted 2", "Sorted 1"} {
+		if found[i].Name != expected {
+			t.Fatalf("Expected item %d to be '%s', got '%s'", i, expected, found[i].Name)
+		}
+	}
+
+	sorted, err := repo.QueryRunner().SortAsc("age").QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query items: %v", err)
+	}
+	if sorted[0].Name != "Sorted 1" {
+		t.Fatalf("Expected explicit sort to override the default, got '%s' first", sorted[0].Name)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	repo := setupTestRepo(t)
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Contextual", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelledRepo := repo.WithContext(cancelled)
+
+	_, err := cancelledRepo.QueryRunner().QueryMany()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected query to fail with the cancelled default context, got %v", err)
+	}
+
+	found, err := cancelledRepo.QueryRunner().QueryMany(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected the terminal context to take precedence, got %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected to find 1 item, but found %d", len(found))
+	}
+}
+
+func TestCountEstimate(t *testing.T) {
+	logger := &capturingLogger{}
+	repo := setupTestRepo(t).WithLogger(logger)
+	items := []TestModel{
+		{Name: "Estimate 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Estimate 2", Age: 30, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	estimate, err := repo.QueryRunner().CountEstimate()
+	if err != nil {
+		t.Fatalf("Failed to estimate count: %v", err)
+	}
+	if estimate != 2 {
+		t.Fatalf("Expected estimate to be 2, got %d", estimate)
+	}
+	if last := logger.queries[len(logger.queries)-1]; last.op != "EstimatedCount" {
+		t.Fatalf("Expected an empty filter to use the estimate, got %s", last.op)
+	}
+
+	count, err := repo.QueryRunner().Filter(`{"age": ?1}`, 30).CountEstimate()
+	if err != nil {
+		t.Fatalf("Failed to count filtered items: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected filtered count to be 1, got %d", count)
+	}
+	if last := logger.queries[len(logger.queries)-1]; last.op != "Count" {
+		t.Fatalf("Expected a filter to use an exact count, got %s", last.op)
+	}
+}
+
+func TestQueryFirst(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "First 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "First 2", Age: 50, CreatedAt: time.Now()},
+		{Name: "First 3", Age: 30, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	oldest, err := repo.QueryRunner().SortDesc("age").First()
+	if err != nil {
+		t.Fatalf("Failed to query first item: %v", err)
+	}
+	if oldest.Name != "First 2" {
+		t.Fatalf("Expected the oldest item 'First 2', got '%s'", oldest.Name)
+	}
+
+	_, err = repo.QueryRunner().Filter(`{"age": ?1}`, 99).First()
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound when nothing matches, got %v", err)
+	}
+}
+
+func TestPointerModelRejected(t *testing.T) {
+	_, err := NewMongoRepository[*TestModel](nil)
+	if err == nil || !strings.Contains(err.Error(), "must not be a pointer") {
+		t.Fatalf("Expected pointer model to be rejected, got %v", err)
+	}
+
+	_, err = NewInMemoryRepository[*TestModel]()
+	if err == nil || !strings.Contains(err.Error(), "must not be a pointer") {
+		t.Fatalf("Expected pointer model to be rejected by in memory repository, got %v", err)
+	}
+}
+
+func TestEnsureUniqueIndex(t *testing.T) {
+	// CustomerModel declares no index tags, so only EnsureUniqueIndex can reject duplicates
+	repo, err := NewMongoRepository[CustomerModel](setupTestCollection(t, "customers"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := repo.Save(context.TODO(), CustomerModel{Name: "Unindexed"}); err != nil {
+			t.Fatalf("Expected duplicates to be accepted without an index, got %v", err)
+		}
+	}
+
+	if _, err := repo.DeleteAll(context.TODO()); err != nil {
+		t.Fatalf("Failed to delete duplicates: %v", err)
+	}
+
+	name, err := repo.EnsureUniqueIndex(context.TODO(), bson.D{{Key: "name", Value: 1}})
+	if err != nil {
+		t.Fatalf("Failed to create unique index: %v", err)
+	}
+	if name != "name_1" {
+		t.Fatalf("Expected index name 'name_1', got '%s'", name)
+	}
+
+	if _, err := repo.Save(context.TODO(), CustomerModel{Name: "Unique"}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	_, err = repo.Save(context.TODO(), CustomerModel{Name: "Unique"})
+	if !IsDuplicateKeyError(err) {
+		t.Fatalf("Expected duplicate key error after creating unique index, got %v", err)
+	}
+}
+
+func TestRegexFilters(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Johnny", Age: 20, CreatedAt: time.Now()},
+		{Name: "johanna", Age: 30, CreatedAt: time.Now()},
+		{Name: "Bob (admin)", Age: 40, CreatedAt: time.Now()},
+		{Name: "Bob admin", Age: 50, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	prefixed, err := repo.QueryRunner().
+		Regex("name", "^jo", "i").
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query by regex: %v", err)
+	}
+	if len(prefixed) != 2 {
+		t.Fatalf("Expected 2 case insensitive prefix matches, got %d", len(prefixed))
+	}
+
+	literal, err := repo.QueryRunner().
+		RegexLiteral("name", "(admin)", "").
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query by literal regex: %v", err)
+	}
+	if len(literal) != 1 || literal[0].Name != "Bob (admin)" {
+		t.Fatalf("Expected only 'Bob (admin)' to match literally, got %v", literal)
+	}
+}
+
+type LineItem struct {
+	Qty   int     `bson:"qty"`
+	Price float64 `bson:"price"`
+}
+
+type CartModel struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Name  string             `bson:"name"`
+	Items []LineItem         `bson:"items"`
+}
+
+func TestElemMatch(t *testing.T) {
+	repo, err := NewMongoRepository[CartModel](setupTestCollection(t, "carts"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	carts := []CartModel{
+		{Name: "Matching", Items: []LineItem{{Qty: 1, Price: 20}, {Qty: 6, Price: 5}}},
+		// each condition is met, but by different items
+		{Name: "Split", Items: []LineItem{{Qty: 6, Price: 20}, {Qty: 1, Price: 5}}},
+		{Name: "Empty"},
+	}
+	if _, err := repo.SaveAll(context.TODO(), carts); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	found, err := repo.QueryRunner().
+		ElemMatch("items", bson.M{"qty": bson.M{"$gt": 5}, "price": bson.M{"$lt": 10}}).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query by elemMatch: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "Matching" {
+		t.Fatalf("Expected only 'Matching' to have a matching item, got %v", found)
+	}
+}
+
+func TestInSliceObjectIds(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "In 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "In 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "In 3", Age: 40, CreatedAt: time.Now()},
+	}
+	savedItems, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	found, err := repo.QueryRunner().
+		InSlice("_id", []primitive.ObjectID{savedItems[0].ID, savedItems[2].ID}).
+		NotIn("name", "In 3").
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query by ids: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "In 1" {
+		t.Fatalf("Expected only 'In 1' to match, got %v", found)
+	}
+}
+
+func TestQueryD(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Range 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Range 2", Age: 30, CreatedAt: time.Now()},
+		{Name: "Range 3", Age: 40, CreatedAt: time.Now()},
+	}
+	if _, err := repo.SaveAll(context.TODO(), items); err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	found, err := repo.QueryD(context.TODO(), bson.D{
+		{Key: "age", Value: bson.D{{Key: "$gte", Value: 25}, {Key: "$lt", Value: 40}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to query with an ordered filter: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "Range 2" {
+		t.Fatalf("Expected only 'Range 2' to match, got %v", found)
+	}
+
+	count, err := repo.QueryRunner().
+		FilterD(bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 20}}}}).
+		NotIn("name", "Range 1").
+		Count()
+	if err != nil {
+		t.Fatalf("Failed to count with an ordered filter: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 items to match, got %d", count)
+	}
+}
+
+func TestHint(t *testing.T) {
+	repo := setupTestRepo(t)
+	if _, err := repo.EnsureIndex(context.TODO(), bson.D{{Key: "name", Value: 1}}); err != nil {
+		t.Fatalf("Failed to create name index: %v", err)
+	}
+	if _, err := repo.EnsureIndex(context.TODO(), bson.D{{Key: "age", Value: 1}, {Key: "name", Value: 1}}); err != nil {
+		t.Fatalf("Failed to create age index: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Hinted", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	plan, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		Hint("name_1").
+		Explain(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to explain hinted query: %v", err)
+	}
+	winningPlan := fmt.Sprint(plan["queryPlanner"].(bson.M)["winningPlan"])
+	if !strings.Contains(winningPlan, "name_1") {
+		t.Fatalf("Expected hinted index 'name_1' in winning plan, got %s", winningPlan)
+	}
+
+	count, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 30).
+		Hint(bson.D{{Key: "age", Value: 1}, {Key: "name", Value: 1}}).
+		Count()
+	if err != nil {
+		t.Fatalf("Failed to count with hint: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected count of 1, got %d", count)
+	}
+}
+
+func TestFindFirstAndLast(t *testing.T) {
+	repo := setupTestRepo(t)
+	byCreatedAt := bson.D{{Key: "created_at", Value: 1}}
+
+	_, err := repo.FindFirst(context.TODO(), byCreatedAt)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected not found error on empty collection, got %v", err)
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	items := []TestModel{
+		{Name: "Middle", Age: 30, CreatedAt: now},
+		{Name: "Oldest", Age: 40, CreatedAt: now.Add(-time.Hour)},
+		{Name: "Newest", Age: 20, CreatedAt: now.Add(time.Hour)},
+	}
+	_, err = repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	first, err := repo.FindFirst(context.TODO(), byCreatedAt)
+	if err != nil {
+		t.Fatalf("Failed to find first item: %v", err)
+	}
+	if first.Name != "Oldest" {
+		t.Fatalf("Expected first item to be 'Oldest', got '%s'", first.Name)
+	}
+
+	last, err := repo.FindLast(context.TODO(), byCreatedAt)
+	if err != nil {
+		t.Fatalf("Failed to find last item: %v", err)
+	}
+	if last.Name != "Newest" {
+		t.Fatalf("Expected last item to be 'Newest', got '%s'", last.Name)
+	}
+}
+
+func TestQueryRaw(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Raw 1", Age: 20, CreatedAt: time.Now()},
+		{Name: "Raw 2", Age: 30, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	docs, err := repo.QueryRunner().
+		FilterB(bson.M{}).
+		Projection(`{"_id":0,"name":1,"age":1}`).
+		QueryManyRaw()
+	if err != nil {
+		t.Fatalf("Failed to query raw documents: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 raw documents, got %d", len(docs))
+	}
+	for _, doc := range docs {
+		if _, ok := doc["name"]; !ok || len(doc) != 2 {
+			t.Fatalf("Expected only name & age in raw document, got %v", doc)
+		}
+		if _, ok := doc["age"]; !ok {
+			t.Fatalf("Expected only name & age in raw document, got %v", doc)
+		}
+	}
+
+	doc, err := repo.QueryRunner().
+		Filter(`{"name": ?1}`, "Raw 2").
+		Projection(`{"_id":0,"name":1,"age":1}`).
+		QueryOneRaw()
+	if err != nil {
+		t.Fatalf("Failed to query raw document: %v", err)
+	}
+	if !reflect.DeepEqual(doc, bson.M{"name": "Raw 2", "age": int32(30)}) {
+		t.Fatalf("Expected raw document of 'Raw 2', got %v", doc)
+	}
+
+	_, err = repo.QueryRunner().Filter(`{"name": ?1}`, "Missing").QueryOneRaw()
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for no matching document, got %v", err)
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	repo := setupTestRepo(t)
+	_, err := repo.Collection().InsertMany(context.TODO(), []interface{}{
+		bson.M{"full_name": "Legacy 1", "age": 20},
+		bson.M{"full_name": "Legacy 2", "age": 30},
+		bson.M{"name": "Current", "age": 40},
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert legacy documents: %v", err)
+	}
+
+	modified, err := repo.RenameField(context.TODO(), "full_name", "name")
+	if err != nil {
+		t.Fatalf("Failed to rename field: %v", err)
+	}
+	if modified != 2 {
+		t.Fatalf("Expected 2 documents to be modified, got %d", modified)
+	}
+
+	items, err := repo.QueryRunner().
+		FilterB(bson.M{}).
+		Sort(`{"age":1}`).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query items: %v", err)
+	}
+	if len(items) != 3 || items[0].Name != "Legacy 1" || items[1].Name != "Legacy 2" || items[2].Name != "Current" {
+		t.Fatalf("Expected renamed field to populate name, got %+v", items)
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	repo := setupTestRepo(t)
+	_, err := repo.Collection().InsertMany(context.TODO(), []interface{}{
+		bson.M{"name": "Unversioned 1", "age": 20},
+		bson.M{"name": "Unversioned 2", "age": 30},
+		bson.M{"name": "Versioned", "age": 40, "version": 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert documents: %v", err)
+	}
+
+	missing := bson.M{"version": bson.M{"$exists": false}}
+	modified, err := repo.Backfill(context.TODO(), bson.M{"version": 0}, missing)
+	if err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	if modified != 2 {
+		t.Fatalf("Expected 2 documents to be backfilled, got %d", modified)
+	}
+
+	count, err := repo.QueryRunner().FilterB(bson.M{"version": 0}).Count()
+	if err != nil {
+		t.Fatalf("Failed to count backfilled documents: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 documents at version 0, got %d", count)
+	}
+	count, err = repo.QueryRunner().FilterB(bson.M{"version": 3}).Count()
+	if err != nil {
+		t.Fatalf("Failed to count versioned documents: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected the versioned document to keep its version, got %d", count)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	repo := setupTestRepo(t)
+	items := []TestModel{
+		{Name: "Keep", Age: 20, CreatedAt: time.Now()},
+		{Name: "Drop 1", Age: 60, CreatedAt: time.Now()},
+		{Name: "Drop 2", Age: 60, CreatedAt: time.Now()},
+	}
+	_, err := repo.SaveAll(context.TODO(), items)
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	modified, err := repo.QueryRunner().
+		Filter(`{"age": ?1}`, 60).
+		Unset("created_at")
+	if err != nil {
+		t.Fatalf("Failed to unset field: %v", err)
+	}
+	if modified != 2 {
+		t.Fatalf("Expected 2 documents to be modified, got %d", modified)
+	}
+
+	withField, err := repo.QueryRunner().
+		Filter(`{"created_at": {"$exists": true}}`).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query items: %v", err)
+	}
+	if len(withField) != 1 || withField[0].Name != "Keep" {
+		t.Fatalf("Expected only the untouched item to keep the field, got %+v", withField)
+	}
+}
+
+type TimelineModel struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Events []string           `bson:"events"`
+}
+
+func TestProjectSlice(t *testing.T) {
+	repo, err := NewMongoRepository[TimelineModel](setupTestCollection(t, "timelines"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	saved, err := repo.Save(context.TODO(), TimelineModel{Events: []string{"first", "second", "third", "fourth"}})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	found, err := repo.QueryRunner().
+		FilterB(bson.M{"_id": saved.ID}).
+		ProjectSlice("events", 2).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query sliced item: %v", err)
+	}
+	if len(found) != 1 || !reflect.DeepEqual(found[0].Events, []string{"first", "second"}) {
+		t.Fatalf("Expected events sliced to the first two, got %+v", found)
+	}
+}
+
+func TestSaveAllResult(t *testing.T) {
+	collection := setupTestCollection(t, "accounts")
+	repo, err := NewMongoRepository[AccountModel](collection)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), AccountModel{Email: "taken@example.com"}); err != nil {
+		t.Fatalf("Failed to save existing account: %v", err)
+	}
+
+	items := []AccountModel{
+		{Email: "first@example.com"},
+		{Email: "taken@example.com"},
+		{Email: "last@example.com"},
+	}
+	results, err := repo.SaveAllResult(context.TODO(), items)
+	if err == nil {
+		t.Fatalf("Expected an error reporting the failed item")
+	}
+	if len(results) != len(items) {
+		t.Fatalf("Expected %d results, got %d", len(items), len(results))
+	}
+	if !results[0].Success || !results[2].Success {
+		t.Fatalf("Expected the other items to succeed, got %+v", results)
+	}
+	if results[1].Success || !IsDuplicateKeyError(results[1].Err) {
+		t.Fatalf("Expected only the duplicate item to fail with a duplicate key error, got %+v", results[1])
+	}
+	if results[0].ID != items[0].ID {
+		t.Fatalf("Expected result id %v to match item id %v", results[0].ID, items[0].ID)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 accounts to be stored, got %d", count)
+	}
+}
+
+func TestSaveAllResultEmpty(t *testing.T) {
+	repo := &MongoRepository[TestModel]{}
+	results, err := repo.SaveAllResult(context.TODO(), nil)
+	if err != nil || results != nil {
+		t.Fatalf("Expected no results & no error for no items, got %v & %v", results, err)
+	}
+}
+
+type BudgetModel struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Name   string             `bson:"name"`
+	Spent  int                `bson:"spent"`
+	Budget int                `bson:"budget"`
+}
+
+func TestFilterExprComparesFields(t *testing.T) {
+	repo, err := NewMongoRepository[BudgetModel](setupTestCollection(t, "budgets"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	_, err = repo.SaveAll(context.TODO(), []BudgetModel{
+		{Name: "Over", Spent: 150, Budget: 100},
+		{Name: "Under", Spent: 50, Budget: 100},
+		{Name: "Exact", Spent: 100, Budget: 100},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save items: %v", err)
+	}
+
+	overBudget, err := repo.QueryRunner().
+		Filter(`{"$expr":{"$gt":["$spent","$budget"]}}`).
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query with $expr: %v", err)
+	}
+	if len(overBudget) != 1 || overBudget[0].Name != "Over" {
+		t.Fatalf("Expected only 'Over' to exceed its budget, got %+v", overBudget)
+	}
+}
+
+func TestConflictingIndexTolerated(t *testing.T) {
+	collection := setupTestCollection(t, "conflicts")
+	_, err := collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetName("email_1").SetSparse(true),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create conflicting index: %v", err)
+	}
+
+	logger := &capturingLogger{}
+	if _, err := NewMongoRepository[AccountModel](collection, UseLogger(logger)); err != nil {
+		t.Fatalf("Expected conflicting index to be tolerated, got %v", err)
+	}
+	if len(logger.queries) != 1 || logger.queries[0].op != "CreateIndex" || logger.queries[0].err == nil {
+		t.Fatalf("Expected the index conflict to be logged, got %+v", logger.queries)
+	}
+
+	if _, err := NewMongoRepository[AccountModel](collection, StrictIndexes()); err == nil {
+		t.Fatalf("Expected strict construction to fail on the conflicting index")
+	}
+}
+
+func TestSkipIndexCreation(t *testing.T) {
+	collection := setupTestCollection(t, "unindexed")
+	repo, err := NewMongoRepository[TestModel](collection, SkipIndexCreation())
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Unindexed", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+
+	keys := listIndexKeys(t, collection)
+	if len(keys) != 1 || keys[0][0].Key != "_id" {
+		t.Fatalf("Expected only the _id index, got %v", keys)
+	}
+
+	collection = setupTestCollection(t, "unindexed_struct")
+	repo, err = NewMongoRepositoryWithOptions[TestModel](collection, RepoOptions{SkipIndexCreation: true})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Unindexed", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	keys = listIndexKeys(t, collection)
+	if len(keys) != 1 || keys[0][0].Key != "_id" {
+		t.Fatalf("Expected only the _id index with RepoOptions, got %v", keys)
+	}
+}
@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WithRetry returns a copy of the repository retrying operations that fail with a
+// network, timeout or transient transaction error, doubling backoff between attempts.
+// Writes that aren't idempotent, such as inserts, are only retried on errors the server
+// labels RetryableWriteError, as another attempt could apply them twice.
+func (r *MongoRepository[T]) WithRetry(maxAttempts int, backoff time.Duration) *MongoRepository[T] {
+	clone := r.clone()
+	clone.retryAttempts = maxAttempts
+	clone.retryBackoff = backoff
+	return clone
+}
+
+// nonIdempotentOps are the writes whose repetition after an unacknowledged success would apply
+// them twice, inserting documents again or re-running update operators such as $inc
+var nonIdempotentOps = map[string]bool{"Bulk": true, "InsertMany": true, "UpdateByIdAndReturn": true}
+
+func (r *MongoRepository[T]) retry(ctx context.Context, idempotent bool, operation func(ctx context.Context) error) error {
+	delay := r.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := operation(ctx)
+		if err == nil || attempt >= r.retryAttempts || !isRetryable(err, idempotent) || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+func isRetryable(err error, idempotent bool) bool {
+	var serverErr mongo.ServerError
+	hasServerErr := errors.As(err, &serverErr)
+	if !idempotent {
+		return hasServerErr && serverErr.HasErrorLabel("RetryableWriteError")
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	return hasServerErr && serverErr.HasErrorLabel("TransientTransactionError")
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRetryTransientError(t *testing.T) {
+	repo := (&MongoRepository[TestModel]{}).WithRetry(3, time.Millisecond)
+
+	attempts := 0
+	err := repo.retry(context.TODO(), true, func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}}
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected operation to succeed after retry, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("Expected 2 attempts, but got %d", attempts)
+	}
+}
+
+func TestRetrySkipsNonRetryableError(t *testing.T) {
+	repo := (&MongoRepository[TestModel]{}).WithRetry(3, time.Millisecond)
+
+	attempts := 0
+	err := repo.retry(context.TODO(), true, func(ctx context.Context) error {
+		attempts++
+		return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: duplicateKeyCode}}}
+	})
+
+	if !IsDuplicateKeyError(err) {
+		t.Fatalf("Expected duplicate key error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("Expected duplicate key error not to be retried, but got %d attempts", attempts)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	repo := (&MongoRepository[TestModel]{}).WithRetry(3, time.Millisecond)
+
+	attempts := 0
+	err := repo.retry(context.TODO(), true, func(ctx context.Context) error {
+		attempts++
+		return mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}}
+	})
+
+	if err == nil {
+		t.Fatalf("Expected error after exhausting retries")
+	}
+	if attempts != 3 {
+		t.Fatalf("Expected 3 attempts, but got %d", attempts)
+	}
+}
+
+func TestRetryNonIdempotentWrite(t *testing.T) {
+	repo := (&MongoRepository[TestModel]{}).WithRetry(3, time.Millisecond)
+
+	attempts := 0
+	err := repo.retry(context.TODO(), false, func(ctx context.Context) error {
+		attempts++
+		return mongo.CommandError{Code: 6, Labels: []string{"NetworkError"}}
+	})
+	if err == nil || attempts != 1 {
+		t.Fatalf("Expected a network error on an insert not to be retried, got %d attempts", attempts)
+	}
+
+	attempts = 0
+	err = repo.retry(context.TODO(), false, func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return mongo.CommandError{Code: 91, Labels: []string{"RetryableWriteError"}}
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("Expected a RetryableWriteError to be retried, got %d attempts & %v", attempts, err)
+	}
+}
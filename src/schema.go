@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const namespaceNotFoundCode = 26
+
+// EnsureSchemaValidation makes the server reject inserts & updates of documents not matching the
+// $jsonSchema schema, creating the collection when it doesn't exist yet.
+func (r *MongoRepository[T]) EnsureSchemaValidation(ctx context.Context, schema bson.M) error {
+	validator := bson.M{"$jsonSchema": schema}
+	err := r.execute(ctx, "EnsureSchemaValidation", validator, func(ctx context.Context) error {
+		command := bson.D{{Key: "collMod", Value: r.collection.Name()}, {Key: "validator", Value: validator}}
+		return r.collection.Database().RunCommand(ctx, command).Err()
+	})
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != namespaceNotFoundCode {
+		return err
+	}
+	return r.execute(ctx, "EnsureSchemaValidation", validator, func(ctx context.Context) error {
+		return r.collection.Database().CreateCollection(ctx, r.collection.Name(), options.CreateCollection().SetValidator(validator))
+	})
+}
+
+// ensureTagSchema applies the schema derived from the schema tags of T, if any
+func (r *MongoRepository[T]) ensureTagSchema() error {
+	schema, err := schemaFromTags(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil || schema == nil {
+		return err
+	}
+	return r.EnsureSchemaValidation(context.Background(), schema)
+}
+
+var bytesType = reflect.TypeOf([]byte{})
+
+// schemaFromTags derives a $jsonSchema from the fields of t tagged with schema:"required" or
+// schema:"type", constraining their bson type. It returns nil when no field is tagged.
+func schemaFromTags(t reflect.Type) (bson.M, error) {
+	properties := bson.M{}
+	required := bson.A{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("schema")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		fieldName := getFieldName(field)
+		switch strings.TrimSpace(tag) {
+		case "required":
+			required = append(required, fieldName)
+		case "type":
+		default:
+			return nil, fmt.Errorf("unsupported schema tag on field %s: %s", fieldName, tag)
+		}
+		property := bson.M{}
+		if bsonTypes := schemaBsonTypes(field.Type); len(bsonTypes) > 0 {
+			property["bsonType"] = bsonTypes
+		}
+		properties[fieldName] = property
+	}
+	if len(properties) == 0 {
+		return nil, nil
+	}
+	schema := bson.M{"bsonType": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// schemaBsonTypes returns the bson types a field of type t is stored as, or nil when any type is possible
+func schemaBsonTypes(t reflect.Type) bson.A {
+	if t.Kind() == reflect.Ptr {
+		if types := schemaBsonTypes(t.Elem()); types != nil {
+			return append(types, "null")
+		}
+		return nil
+	}
+	switch {
+	case t == timeType:
+		return bson.A{"date"}
+	case t == objectIdType:
+		return bson.A{"objectId"}
+	case t == decimal128Type:
+		return bson.A{"decimal"}
+	case t == bytesType:
+		return bson.A{"binData"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return bson.A{"string"}
+	case reflect.Bool:
+		return bson.A{"bool"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		// integers are stored as int32 when they fit, int64 otherwise
+		return bson.A{"int", "long"}
+	case reflect.Float32, reflect.Float64:
+		return bson.A{"double"}
+	case reflect.Slice, reflect.Array:
+		// nil slices are stored as null
+		return bson.A{"array", "null"}
+	case reflect.Map:
+		return bson.A{"object", "null"}
+	case reflect.Struct:
+		return bson.A{"object"}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SchemaModel struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Name  string             `bson:"name,omitempty" schema:"required"`
+	Score *float64           `bson:"score" schema:"type"`
+	Notes string             `bson:"notes"`
+}
+
+func TestSchemaFromTags(t *testing.T) {
+	schema, err := schemaFromTags(reflect.TypeOf(SchemaModel{}))
+	if err != nil {
+		t.Fatalf("Failed to derive schema: %v", err)
+	}
+
+	expected := bson.M{
+		"bsonType": "object",
+		"required": bson.A{"name"},
+		"properties": bson.M{
+			"name":  bson.M{"bsonType": bson.A{"string"}},
+			"score": bson.M{"bsonType": bson.A{"double", "null"}},
+		},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Fatalf("Expected schema %v, got %v", expected, schema)
+	}
+
+	type invalidModel struct {
+		Name string `bson:"name" schema:"mandatory"`
+	}
+	if _, err := schemaFromTags(reflect.TypeOf(invalidModel{})); err == nil {
+		t.Fatalf("Expected unsupported schema tag to be rejected")
+	}
+}
+
+func TestEnsureSchemaValidation(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.TODO()
+
+	err := repo.EnsureSchemaValidation(ctx, bson.M{
+		"bsonType":   "object",
+		"properties": bson.M{"age": bson.M{"bsonType": bson.A{"int", "long"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to ensure schema validation: %v", err)
+	}
+
+	if _, err := repo.Save(context.TODO(), TestModel{Name: "Valid", Age: 30, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save valid item: %v", err)
+	}
+	_, err = repo.Collection().InsertOne(ctx, bson.M{"name": "Invalid", "age": "thirty"})
+	if err == nil {
+		t.Fatalf("Expected the server to reject a document with a string age")
+	}
+}
+
+func TestTagSchemaValidation(t *testing.T) {
+	repo, err := NewMongoRepository[SchemaModel](setupTestCollection(t, "schemas"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	if _, err := repo.Save(context.TODO(), SchemaModel{Name: "Named"}); err != nil {
+		t.Fatalf("Failed to save valid item: %v", err)
+	}
+	if _, err := repo.Save(context.TODO(), SchemaModel{Notes: "Unnamed"}); err == nil {
+		t.Fatalf("Expected the server to reject an item without a name")
+	}
+}
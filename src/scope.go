@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// WithScope returns a copy of the repository whose reads, counts, deletes, queries & aggregations
+// only match documents matching filter, in addition to any filter of their own. Writes stamp the
+// plain values of filter onto the saved documents & reject updates of its fields. Scoping an
+// already scoped repository adds to its scope, fields that are already scoped keep their value.
+func (r *MongoRepository[T]) WithScope(filter bson.M) *MongoRepository[T] {
+	clone := r.clone()
+	clone.scope = bson.M{}
+	for key, value := range filter {
+		clone.scope[key] = value
+	}
+	for key, value := range r.scope {
+		clone.scope[key] = value
+	}
+	return clone
+}
+
+// scoped ANDs the scope with filter, so that conflicting conditions in filter can't widen the scope
+func (r *MongoRepository[T]) scoped(filter bson.M) bson.M {
+	if len(r.scope) == 0 {
+		return filter
+	}
+	if len(filter) == 0 {
+		return r.scope
+	}
+	return bson.M{"$and": bson.A{r.scope, filter}}
+}
+
+func (r *MongoRepository[T]) scopedPipeline(pipeline []bson.M) []bson.M {
+	scoped := make([]bson.M, 0, len(pipeline)+1)
+	if len(r.scope) > 0 {
+		scoped = append(scoped, bson.M{"$match": r.scope})
+	}
+	return append(scoped, pipeline...)
+}
+
+// scopeKeyOf returns the scope key that setting field would change, being the field itself, one of
+// its sub fields or a document containing it
+func (r *MongoRepository[T]) scopeKeyOf(field string) (string, bool) {
+	for key := range r.scope {
+		if key == field || strings.HasPrefix(key, field+".") || strings.HasPrefix(field, key+".") {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// checkScopeFields rejects writes to fields of the scope, as they would move documents out of it
+func (r *MongoRepository[T]) checkScopeFields(fields ...string) error {
+	for _, name := range fields {
+		if key, ok := r.scopeKeyOf(name); ok {
+			return fmt.Errorf("field %s cannot be written, it would change the scoped field %s", name, key)
+		}
+	}
+	return nil
+}
+
+// updateFields returns the fields written by update, including the targets of $rename. Keys
+// outside operators are fields replaced as is.
+func updateFields(update bson.M) []string {
+	var fields []string
+	for key, value := range update {
+		if !strings.HasPrefix(key, "$") {
+			fields = append(fields, key)
+			continue
+		}
+		for field, target := range operatorFields(value) {
+			fields = append(fields, field)
+			if name, ok := target.(string); ok && key == "$rename" {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields
+}
+
+// operatorFields returns the fields & values of an update operator such as {"$set": {...}}
+func operatorFields(value interface{}) map[string]interface{} {
+	switch fields := value.(type) {
+	case bson.M:
+		return fields
+	case map[string]interface{}:
+		return fields
+	case bson.D:
+		m := make(map[string]interface{}, len(fields))
+		for _, e := range fields {
+			m[e.Key] = e.Value
+		}
+		return m
+	}
+	return nil
+}
+
+// queryFilter returns the scoped filter of query, keeping the order of an ordered filter
+func (r *MongoRepository[T]) queryFilter(query *QueryBuilder[T]) interface{} {
+	if query.orderedFilter == nil {
+		if filter := r.scoped(query.filter); filter != nil {
+			return filter
+		}
+		return bson.M{}
+	}
+	if len(r.scope) == 0 {
+		return query.orderedFilter
+	}
+	return bson.M{"$and": bson.A{r.scope, query.orderedFilter}}
+}
+
+// stampScope sets the fields of item named by the scope to their scoped value, skipping
+// operator conditions such as {"$in": [...]} which have no single value
+func (r *MongoRepository[T]) stampScope(item *T) error {
+	if len(r.scope) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(item).Elem()
+	for key, value := range r.scope {
+		if _, ok := value.(bson.M); ok || strings.HasPrefix(key, "$") {
+			continue
+		}
+		field, ok := fieldByBsonName(v, key)
+		if !ok {
+			return fmt.Errorf("scope field %s is not part of %s", key, v.Type())
+		}
+		scopeValue := reflect.ValueOf(value)
+		if !scopeValue.IsValid() || !scopeValue.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("scope value %v cannot be assigned to field %s of type %s", value, key, field.Type())
+		}
+		field.Set(scopeValue)
+	}
+	return nil
+}
+
+func fieldByBsonName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !isIgnored(t.Field(i)) && getFieldName(t.Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
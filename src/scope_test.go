@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScopedModel struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Tenant string             `bson:"tenant"`
+	Name   string             `bson:"name"`
+}
+
+func TestWithScope(t *testing.T) {
+	repo, err := NewMongoRepository[ScopedModel](setupTestCollection(t, "scoped"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	acme := repo.WithScope(bson.M{"tenant": "acme"})
+	globex := repo.WithScope(bson.M{"tenant": "globex"})
+
+	savedItem, err := acme.Save(context.TODO(), ScopedModel{Name: "Acme Item"})
+	if err != nil {
+		t.Fatalf("Failed to save scoped item: %v", err)
+	}
+	if savedItem.Tenant != "acme" {
+		t.Fatalf("Expected saved item to be stamped with tenant 'acme', got '%s'", savedItem.Tenant)
+	}
+	if _, err := globex.Save(context.TODO(), ScopedModel{Tenant: "acme", Name: "Globex Item"}); err != nil {
+		t.Fatalf("Failed to save scoped item: %v", err)
+	}
+
+	items, err := acme.FindAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to find scoped items: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "Acme Item" {
+		t.Fatalf("Expected only acme's item, got %v", items)
+	}
+
+	leaked, err := acme.QueryRunner().
+		Filter(`{"tenant": ?1}`, "globex").
+		QueryMany()
+	if err != nil {
+		t.Fatalf("Failed to query scoped items: %v", err)
+	}
+	if len(leaked) != 0 {
+		t.Fatalf("Expected conflicting filter to match nothing, got %v", leaked)
+	}
+
+	deletedCount, err := acme.QueryRunner().
+		Filter(`{"name": ?1}`, "Globex Item").
+		Delete()
+	if err != nil {
+		t.Fatalf("Failed to delete scoped items: %v", err)
+	}
+	if deletedCount != 0 {
+		t.Fatalf("Expected scoped delete to leave other tenants alone, deleted %d", deletedCount)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 items in the collection, got %d", count)
+	}
+}
+
+func TestSaveFieldsRejectsScopeFields(t *testing.T) {
+	repo := (&MongoRepository[ScopedModel]{}).WithScope(bson.M{"tenant": "acme", "owner.id": "u1"})
+	item := ScopedModel{Tenant: "globex", Name: "Moved"}
+
+	for _, field := range []string{"tenant", "owner", "owner.id", "tenant.name"} {
+		err := repo.SaveFields(context.TODO(), primitive.NewObjectID(), item, "name", field)
+		if err == nil || !strings.Contains(err.Error(), "scoped field") {
+			t.Fatalf("Expected saving %s to be rejected as a scoped field, got %v", field, err)
+		}
+	}
+
+	ctx := context.TODO()
+	id := primitive.NewObjectID()
+	writes := map[string]func() error{
+		"Upsert": func() error {
+			_, err := repo.Upsert(ctx, bson.M{"name": "Moved"}, nil, bson.M{"tenant": "globex"})
+			return err
+		},
+		"UpdateByIdAndReturn": func() error {
+			_, err := repo.UpdateByIdAndReturn(ctx, id, bson.M{"$set": bson.M{"owner": bson.M{"id": "u2"}}})
+			return err
+		},
+		"UpdateByIdAndReturn without operators": func() error {
+			_, err := repo.UpdateByIdAndReturn(ctx, id, bson.M{"tenant": "globex"})
+			return err
+		},
+		"RenameField": func() error {
+			_, err := repo.RenameField(ctx, "name", "tenant")
+			return err
+		},
+		"Backfill": func() error {
+			_, err := repo.Backfill(ctx, bson.M{"owner.id": "u2"}, bson.M{})
+			return err
+		},
+		"Unset": func() error {
+			_, err := repo.Unset(ctx, repo.QueryRunner(), "tenant")
+			return err
+		},
+		"Bulk Update": func() error {
+			_, err := repo.Bulk().Update(bson.M{}, bson.M{"$unset": bson.D{{Key: "owner.id", Value: ""}}}).Execute(ctx)
+			return err
+		},
+	}
+	for name, write := range writes {
+		if err := write(); err == nil || !strings.Contains(err.Error(), "scoped field") {
+			t.Fatalf("Expected %s to be rejected for writing a scoped field, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type TextSearchOptions struct {
+	// Language sets the stemming & stop words of the search, "none" matching words as they are.
+	// The language of the text index is used when empty.
+	Language string
+	// MinScore excludes documents whose relevance score is below it
+	MinScore float64
+}
+
+// TextSearch finds the documents matching search through the collection's text index, most
+// relevant first. The relevance score of each document is available as the textScore field.
+func (r *MongoRepository[T]) TextSearch(ctx context.Context, search string, opts TextSearchOptions) ([]T, error) {
+	text := bson.M{"$search": search}
+	if opts.Language != "" {
+		text["$language"] = opts.Language
+	}
+	pipeline := []bson.M{
+		{"$match": r.scoped(bson.M{"$text": text})},
+		{"$addFields": bson.M{"textScore": bson.M{"$meta": "textScore"}}},
+	}
+	if opts.MinScore > 0 {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"textScore": bson.M{"$gte": opts.MinScore}}})
+	}
+	pipeline = append(pipeline, bson.M{"$sort": bson.M{"textScore": -1}})
+
+	collection, err := r.readCollection(nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []T
+	err = r.execute(ctx, "TextSearch", pipeline, func(ctx context.Context) error {
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
+	return results, err
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ArticleModel struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Body string             `bson:"body" index:"text"`
+}
+
+func setupArticles(t *testing.T) *MongoRepository[ArticleModel] {
+	repo, err := NewMongoRepository[ArticleModel](setupTestCollection(t, "articles"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	_, err = repo.SaveAll(context.TODO(), []ArticleModel{
+		{Body: "mongo mongo mongo"},
+		{Body: "mongo and a long sentence about many other unrelated things"},
+		{Body: "she runs every morning"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save articles: %v", err)
+	}
+	return repo
+}
+
+func TestTextSearchMinScore(t *testing.T) {
+	repo := setupArticles(t)
+
+	all, err := repo.TextSearch(context.TODO(), "mongo", TextSearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(all) != 2 || all[0].Body != "mongo mongo mongo" {
+		t.Fatalf("Expected 2 matches, most relevant first, got %+v", all)
+	}
+
+	strong, err := repo.TextSearch(context.TODO(), "mongo", TextSearchOptions{MinScore: 0.7})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(strong) != 1 || strong[0].Body != "mongo mongo mongo" {
+		t.Fatalf("Expected only the strong match above the threshold, got %+v", strong)
+	}
+}
+
+func TestTextSearchLanguage(t *testing.T) {
+	repo := setupArticles(t)
+
+	stemmed, err := repo.TextSearch(context.TODO(), "running", TextSearchOptions{Language: "english"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(stemmed) != 1 {
+		t.Fatalf("Expected 'running' to match 'runs' in english, got %+v", stemmed)
+	}
+
+	unstemmed, err := repo.TextSearch(context.TODO(), "running", TextSearchOptions{Language: "none"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(unstemmed) != 0 {
+		t.Fatalf("Expected 'running' not to match 'runs' without stemming, got %+v", unstemmed)
+	}
+}
@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer returns a copy of the repository starting a span for every operation.
+// Spans are named "<collection>.<operation>", e.g. "persons.FindById".
+func (r *MongoRepository[T]) WithTracer(tracer trace.Tracer) *MongoRepository[T] {
+	clone := r.clone()
+	clone.tracer = tracer
+	return clone
+}
+
+func (r *MongoRepository[T]) startSpan(ctx context.Context, op string, filter interface{}) (context.Context, trace.Span) {
+	collection := r.collection.Name()
+	return r.tracer.Start(ctx, collection+"."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.operation", op),
+			attribute.String("db.mongodb.collection", collection),
+			attribute.Int("db.mongodb.filter_size", filterSize(filter)),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// filterSize is the number of top level keys of a filter or stages of a pipeline
+func filterSize(filter interface{}) int {
+	v := reflect.ValueOf(filter)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return v.Len()
+	}
+	return 0
+}
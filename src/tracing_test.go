@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	repo := setupTestRepo(t).WithTracer(provider.Tracer("mongorepo"))
+
+	savedItem, err := repo.Save(context.TODO(), TestModel{Name: "Traced", Age: 30, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save item: %v", err)
+	}
+	_, err = repo.FindById(context.TODO(), savedItem.ID)
+	if err != nil {
+		t.Fatalf("Failed to find item by ID: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, but got %d", len(spans))
+	}
+	if spans[0].Name() != "testcollection.Save" || spans[1].Name() != "testcollection.FindById" {
+		t.Fatalf("Unexpected span names %s, %s", spans[0].Name(), spans[1].Name())
+	}
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var ErrValidation = errors.New("validation failed")
+
+type Validator interface {
+	Validate() error
+}
+
+func validate[T any](item *T) error {
+	v := reflect.ValueOf(item).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			rule = strings.TrimSpace(rule)
+			switch rule {
+			case "required":
+				if v.Field(i).IsZero() {
+					return fmt.Errorf("%w: field %s is required", ErrValidation, t.Field(i).Name)
+				}
+			default:
+				return fmt.Errorf("unsupported validate tag on field %s: %s", t.Field(i).Name, rule)
+			}
+		}
+	}
+
+	if validator, ok := any(item).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+	}
+	return nil
+}
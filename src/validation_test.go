@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ValidatedModel struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Name string             `bson:"name" validate:"required"`
+	Age  int                `bson:"age"`
+}
+
+func (m ValidatedModel) Validate() error {
+	if m.Age < 0 {
+		return errors.New("age cannot be negative")
+	}
+	return nil
+}
+
+func setupValidatedRepo(t *testing.T) *MongoRepository[ValidatedModel] {
+	repo, err := NewMongoRepository[ValidatedModel](setupTestCollection(t, "validated"))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	return repo
+}
+
+func TestValidateRequired(t *testing.T) {
+	repo := setupValidatedRepo(t)
+
+	_, err := repo.Save(context.TODO(), ValidatedModel{Age: 20})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Name") {
+		t.Fatalf("Expected validation error to name the field, got %v", err)
+	}
+
+	_, err = repo.SaveAll(context.TODO(), []ValidatedModel{{Name: "Valid", Age: 20}, {Name: "Invalid", Age: -1}})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	count, err := repo.CountAll(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to get count of items: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected invalid items not to be saved, but db count is %d", count)
+	}
+}
+
+func TestValidatePasses(t *testing.T) {
+	repo := setupValidatedRepo(t)
+
+	savedItem, err := repo.Save(context.TODO(), ValidatedModel{Name: "Valid", Age: 20})
+	if err != nil {
+		t.Fatalf("Failed to save valid item: %v", err)
+	}
+	if savedItem.ID.IsZero() {
+		t.Fatalf("Expected non-zero ID for saved item")
+	}
+}